@@ -0,0 +1,53 @@
+package main
+
+// Alert types reported in AlertEntry.Type
+const (
+	AlertRTTExceeded  = "RTT_EXCEEDED"
+	AlertLossExceeded = "LOSS_EXCEEDED"
+)
+
+// AlertEntry records a hop that crossed one of the configured thresholds
+type AlertEntry struct {
+	Hop       int     `json:"hop" xml:"hop,attr"`
+	Type      string  `json:"type" xml:"type,attr"`
+	Threshold float64 `json:"threshold" xml:"threshold,attr"`
+	Actual    float64 `json:"actual" xml:"actual,attr"`
+	// ConsecutiveIterations is how many iterations in a row, including this
+	// one, the hop raised an alert. It is only set in iteration mode.
+	ConsecutiveIterations int `json:"consecutiveIterations,omitempty" xml:"consecutiveIterations,attr,omitempty"`
+}
+
+// checkThresholds returns an alert for every hop whose average RTT or loss
+// exceeds its threshold. A threshold of 0 disables the check.
+func checkThresholds(hops []HopResult, rttThreshold, lossThreshold float64) []AlertEntry {
+	alerts := []AlertEntry{}
+	for _, hop := range hops {
+		// Hops that did not answer have no RTT to compare
+		if rttThreshold > 0 && len(hop.RTTSamples) > 0 && hop.RTTAvg > rttThreshold {
+			alerts = append(alerts, AlertEntry{Hop: hop.Hop, Type: AlertRTTExceeded, Threshold: rttThreshold, Actual: hop.RTTAvg})
+		}
+		if lossThreshold > 0 && hop.Loss > lossThreshold {
+			alerts = append(alerts, AlertEntry{Hop: hop.Hop, Type: AlertLossExceeded, Threshold: lossThreshold, Actual: hop.Loss})
+		}
+	}
+	return alerts
+}
+
+// updateAlertStreaks counts consecutive alerting iterations per hop and
+// annotates the alerts with the current streak
+func (p *TraceroutePlugin) updateAlertStreaks(alerts []AlertEntry) {
+	alerting := map[int]bool{}
+	for _, alert := range alerts {
+		alerting[alert.Hop] = true
+	}
+
+	streaks := make(map[int]int, len(alerting))
+	for hop := range alerting {
+		streaks[hop] = p.alertStreaks[hop] + 1
+	}
+	p.alertStreaks = streaks
+
+	for i := range alerts {
+		alerts[i].ConsecutiveIterations = streaks[alerts[i].Hop]
+	}
+}
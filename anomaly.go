@@ -0,0 +1,60 @@
+package main
+
+import "math"
+
+const (
+	// anomalySigmas is how many standard deviations from the rolling mean
+	// an RTT must be to count as an anomaly
+	anomalySigmas = 2.0
+
+	// anomalyMinSamples is how many earlier iterations a hop must have
+	// answered before its RTT can be flagged
+	anomalyMinSamples = 5
+)
+
+// RTTAnomaly is a hop whose RTT in this iteration is far from its rolling
+// mean over the previous iterations
+type RTTAnomaly struct {
+	Hop        int     `json:"hop" xml:"hop,attr"`
+	CurrentRTT float64 `json:"currentRTT" xml:"currentRTT,attr"`
+	Mean       float64 `json:"mean" xml:"mean,attr"`
+	StdDev     float64 `json:"stddev" xml:"stddev,attr"`
+	Sigmas     float64 `json:"sigmas" xml:"sigmas,attr"`
+}
+
+// rttAnomalies compares the average RTT of every hop of result with the
+// per-iteration averages in the rolling buffers, so it must run before the
+// result is recorded
+func (p *TraceroutePlugin) rttAnomalies(result TracerouteResult) []RTTAnomaly {
+	anomalies := []RTTAnomaly{}
+	for _, hop := range result.Hops {
+		if len(hop.RTTSamples) == 0 {
+			continue
+		}
+		var averages []float64
+		for _, entry := range p.rolling[hop.Hop] {
+			if len(entry.samples) > 0 {
+				averages = append(averages, entry.avg)
+			}
+		}
+		if len(averages) < anomalyMinSamples {
+			continue
+		}
+
+		_, _, mean, stdDev := rttStats(averages)
+		if stdDev == 0 {
+			continue
+		}
+		sigmas := math.Abs(hop.RTTAvg-mean) / stdDev
+		if sigmas > anomalySigmas {
+			anomalies = append(anomalies, RTTAnomaly{
+				Hop:        hop.Hop,
+				CurrentRTT: hop.RTTAvg,
+				Mean:       mean,
+				StdDev:     stdDev,
+				Sigmas:     sigmas,
+			})
+		}
+	}
+	return anomalies
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// cymruWhoisAddr is the Team Cymru IP to ASN bulk whois service
+	cymruWhoisAddr = "whois.cymru.com:43"
+
+	// asnLookupTimeout bounds the whole bulk whois exchange
+	asnLookupTimeout = 5 * time.Second
+
+	// asnCacheTTL is how long an ASN answer is reused across iterations
+	asnCacheTTL = 5 * time.Minute
+)
+
+// asnInfo is the origin AS of an address
+type asnInfo struct {
+	asn     int
+	org     string
+	country string
+	expires time.Time
+}
+
+// asnCache stores ASN answers per IP address
+type asnCache struct {
+	mu      sync.Mutex
+	entries map[string]asnInfo
+}
+
+// lookupASNs attaches the origin AS number and organization to each hop.
+// Failures leave the fields empty rather than failing the trace.
+func (p *TraceroutePlugin) lookupASNs(ctx context.Context, hops []HopResult) {
+	now := time.Now()
+	cached := map[string]asnInfo{}
+	var missing []string
+
+	p.asnCache.mu.Lock()
+	for _, hop := range hops {
+		if hop.IP == "*" {
+			continue
+		}
+		if _, seen := cached[hop.IP]; seen {
+			continue
+		}
+		if info, ok := p.asnCache.entries[hop.IP]; ok && now.Before(info.expires) {
+			cached[hop.IP] = info
+			continue
+		}
+		cached[hop.IP] = asnInfo{}
+		missing = append(missing, hop.IP)
+	}
+	p.asnCache.mu.Unlock()
+
+	if len(missing) > 0 {
+		answers, err := queryCymru(ctx, missing)
+		if err == nil {
+			p.asnCache.mu.Lock()
+			if p.asnCache.entries == nil {
+				p.asnCache.entries = map[string]asnInfo{}
+			}
+			for ip, info := range answers {
+				info.expires = now.Add(asnCacheTTL)
+				p.asnCache.entries[ip] = info
+				cached[ip] = info
+			}
+			p.asnCache.mu.Unlock()
+		}
+	}
+
+	for i := range hops {
+		if info, ok := cached[hops[i].IP]; ok {
+			hops[i].ASN = info.asn
+			hops[i].ASNOrg = info.org
+		}
+	}
+}
+
+// queryCymru resolves a batch of addresses over a single whois connection
+// using the bulk query format
+func queryCymru(ctx context.Context, ips []string) (map[string]asnInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, asnLookupTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", cymruWhoisAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to whois: %v", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	query := "begin\nverbose\n" + strings.Join(ips, "\n") + "\nend\n"
+	if _, err := conn.Write([]byte(query)); err != nil {
+		return nil, fmt.Errorf("failed to send whois query: %v", err)
+	}
+
+	answers := map[string]asnInfo{}
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		ip, info, ok := parseCymruLine(scanner.Text())
+		if ok {
+			answers[ip] = info
+		}
+	}
+	if err := scanner.Err(); err != nil && len(answers) == 0 {
+		return nil, fmt.Errorf("failed to read whois response: %v", err)
+	}
+
+	return answers, nil
+}
+
+// parseCymruLine parses a verbose bulk response line such as
+// "15169   | 8.8.8.8  | 8.8.8.0/24 | US | arin | 2023-12-28 | GOOGLE, US".
+// Unrouted addresses report "NA" as their AS number and are kept with a
+// zero ASN so they are not queried again until the cache expires.
+func parseCymruLine(line string) (string, asnInfo, bool) {
+	fields := strings.Split(line, "|")
+	if len(fields) < 2 {
+		return "", asnInfo{}, false
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	addr := net.ParseIP(fields[1])
+	if addr == nil {
+		// Header line or malformed data
+		return "", asnInfo{}, false
+	}
+	ip := addr.String()
+
+	var info asnInfo
+	if fields[0] != "NA" {
+		asn, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return "", asnInfo{}, false
+		}
+		info.asn = asn
+	}
+	if len(fields) >= 4 && fields[3] != "NA" {
+		info.country = fields[3]
+	}
+	if len(fields) >= 7 && fields[6] != "NA" {
+		info.org = fields[6]
+	}
+
+	return ip, info, true
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// asyncNames collects the hostnames found by the background lookups of
+// asyncDNS traces until Flush applies them to the history
+type asyncNames struct {
+	mu    sync.RWMutex
+	names map[string]string // IP address -> hostname
+	wg    sync.WaitGroup
+}
+
+// resolveInBackground runs resolve on hops in a goroutine and records the
+// names it finds. The lookups are not tied to the context of the trace,
+// which usually ends before they do, they are bounded by their timeouts.
+func (a *asyncNames) resolveInBackground(hops []HopResult, resolve func(ctx context.Context, hops []HopResult) []HopResult) {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		resolved := resolve(context.Background(), hops)
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.names == nil {
+			a.names = map[string]string{}
+		}
+		for _, hop := range resolved {
+			if hop.IP != "*" && hop.Name != "" && hop.Name != hop.IP {
+				a.names[hop.IP] = hop.Name
+			}
+		}
+	}()
+}
+
+// Flush waits for the background hostname lookups of asyncDNS traces to
+// complete and fills in the names of the hops in the stored results. When
+// ctx ends first the names found so far are applied and ctx.Err() is
+// returned. Results already returned by Execute are not changed.
+func (p *TraceroutePlugin) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.asyncNames.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	p.asyncNames.mu.RLock()
+	for i := range p.Results {
+		// The hops are shared with the result Execute returned
+		hops := slices.Clone(p.Results[i].Hops)
+		for j := range hops {
+			if name, ok := p.asyncNames.names[hops[j].IP]; ok && hops[j].Name == hops[j].IP {
+				hops[j].Name = name
+			}
+		}
+		p.Results[i].Hops = hops
+		if err == nil {
+			p.Results[i].DNSPending = false
+		}
+	}
+	p.asyncNames.mu.RUnlock()
+
+	// Every lookup has finished and been applied
+	if err == nil {
+		p.asyncNames.mu.Lock()
+		p.asyncNames.names = nil
+		p.asyncNames.mu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package main
+
+// HopAvailability counts the probes sent to a hop number over all
+// iterations and how many of them were answered
+type HopAvailability struct {
+	TotalProbes      int     `json:"totalProbes"`
+	ResponsiveProbes int     `json:"responsiveProbes"`
+	Availability     float64 `json:"availability"`
+}
+
+// recordAvailability adds the probes of an iteration to the per-hop counts
+func (p *TraceroutePlugin) recordAvailability(result TracerouteResult) {
+	if p.availability == nil {
+		p.availability = map[int]HopAvailability{}
+	}
+	for _, hop := range result.Hops {
+		a := p.availability[hop.Hop]
+		a.TotalProbes += hop.ProbesSent
+		a.ResponsiveProbes += len(hop.RTTSamples)
+		if a.TotalProbes > 0 {
+			a.Availability = float64(a.ResponsiveProbes) / float64(a.TotalProbes) * 100
+		}
+		p.availability[hop.Hop] = a
+	}
+}
+
+// HopAvailability returns the availability of every hop number seen since
+// the iteration state was last reset
+func (p *TraceroutePlugin) HopAvailability() map[int]HopAvailability {
+	availability := make(map[int]HopAvailability, len(p.availability))
+	for hop, a := range p.availability {
+		availability[hop] = a
+	}
+	return availability
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultBatchParallelism is how many batch targets are traced at once when
+// no parallelism is given
+const defaultBatchParallelism = 4
+
+// BatchTarget is a host to trace as part of a batch with the parameters
+// of its trace. The host overrides any host in Params.
+type BatchTarget struct {
+	Host   string
+	Params map[string]interface{}
+}
+
+// BatchResult is the outcome of tracing one batch target
+type BatchResult struct {
+	Host   string
+	Result TracerouteResult
+	Err    error
+}
+
+// ExecuteBatch traces the targets with up to parallelism traces at once, or
+// Config.MaxConcurrency when parallelism is not positive, and returns their
+// results in the order of targets. Every trace runs on its
+// own plugin instance sharing p's configuration, so iteration state does
+// not mix between targets or with p.
+func (p *TraceroutePlugin) ExecuteBatch(ctx context.Context, targets []BatchTarget, parallelism int) []BatchResult {
+	if parallelism < 1 {
+		parallelism = p.Config.MaxConcurrency
+	}
+	if parallelism < 1 {
+		parallelism = defaultBatchParallelism
+	}
+
+	results := make([]BatchResult, len(targets))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, target BatchTarget) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			params := make(map[string]interface{}, len(target.Params)+1)
+			for k, v := range target.Params {
+				params[k] = v
+			}
+			params["host"] = target.Host
+
+			results[i] = BatchResult{Host: target.Host}
+			result, err := p.batchPlugin().Execute(ctx, params)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Result, _ = result.(TracerouteResult)
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+// batchPlugin returns a fresh plugin with p's configuration for one batch
+// trace. The history file is left out as it belongs to p's iterations.
+func (p *TraceroutePlugin) batchPlugin() *TraceroutePlugin {
+	cfg := p.Config
+	cfg.HistoryFile = ""
+	batch := NewPluginWithConfig(cfg)
+	batch.Resolver = p.Resolver
+	return batch
+}
+
+// batchEntry is how a batch result is printed by --batch-file
+type batchEntry struct {
+	Host   string            `json:"host"`
+	Result *TracerouteResult `json:"result,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// batchReport is the output of --batch-file, entries follow the file order
+type batchReport struct {
+	Results []batchEntry `json:"results"`
+}
+
+// readBatchFile parses a batch file. Each line is a host optionally
+// followed by a JSON object of parameters overriding the common ones,
+// blank lines and lines starting with # are skipped. A malformed override
+// is returned in errs at the index of its target instead of failing the
+// whole file.
+func readBatchFile(path string) (targets []BatchTarget, errs []error, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open batch file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		target := BatchTarget{Host: line}
+		var targetErr error
+		if host, override, ok := strings.Cut(line, " "); ok {
+			target.Host = host
+			if err := json.Unmarshal([]byte(override), &target.Params); err != nil {
+				targetErr = fmt.Errorf("line %d: invalid parameters: %v", lineNumber, err)
+			}
+		}
+		targets = append(targets, target)
+		errs = append(errs, targetErr)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read batch file: %v", err)
+	}
+	return targets, errs, nil
+}
+
+// runBatch traces every target of the batch file with ExecuteBatch. common
+// holds the parameters shared by all targets, its overallTimeout bounds the
+// whole batch rather than each trace.
+func runBatch(plugin *TraceroutePlugin, path string, common map[string]interface{}, parallelism int) (batchReport, error) {
+	targets, errs, err := readBatchFile(path)
+	if err != nil {
+		return batchReport{}, err
+	}
+
+	ctx := context.Background()
+	timeout := plugin.Config.DefaultTimeout
+	in := paramReader{params: common}
+	if overallTimeout, ok := in.float("overallTimeout"); ok {
+		timeout = time.Duration(overallTimeout * float64(time.Second))
+	}
+	if in.err != nil {
+		return batchReport{}, in.err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Targets with a malformed line are reported without tracing them
+	report := batchReport{Results: make([]batchEntry, len(targets))}
+	var valid []BatchTarget
+	var positions []int
+	for i, target := range targets {
+		report.Results[i].Host = target.Host
+		if errs[i] != nil {
+			report.Results[i].Error = errs[i].Error()
+			continue
+		}
+
+		// The batch deadline applies unless a target sets its own timeout
+		params := make(map[string]interface{}, len(common)+len(target.Params))
+		for k, v := range common {
+			params[k] = v
+		}
+		delete(params, "overallTimeout")
+		for k, v := range target.Params {
+			params[k] = v
+		}
+		valid = append(valid, BatchTarget{Host: target.Host, Params: params})
+		positions = append(positions, i)
+	}
+
+	for i, result := range plugin.ExecuteBatch(ctx, valid, parallelism) {
+		entry := &report.Results[positions[i]]
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+			continue
+		}
+		traceResult := result.Result
+		entry.Result = &traceResult
+	}
+	return report, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// BinaryFlavor identifies the command line and output dialect of the system
+// traceroute binary
+type BinaryFlavor int
+
+const (
+	// FlavorLinux is the traceroute shipped with Linux distributions
+	FlavorLinux BinaryFlavor = iota
+	// FlavorBSD is the traceroute found on macOS and the BSDs
+	FlavorBSD
+	// FlavorWindows is the Windows tracert command
+	FlavorWindows
+)
+
+// detectTracerouteBinary locates the traceroute binary for the current
+// platform and reports which dialect it speaks
+func detectTracerouteBinary() (string, BinaryFlavor, error) {
+	var flavor BinaryFlavor
+	var candidates []string
+
+	switch runtime.GOOS {
+	case "windows":
+		flavor = FlavorWindows
+		candidates = []string{"tracert", `C:\Windows\System32\tracert.exe`}
+	case "darwin", "freebsd", "openbsd", "netbsd", "dragonfly":
+		flavor = FlavorBSD
+		candidates = []string{"traceroute", "/usr/sbin/traceroute"}
+	default:
+		flavor = FlavorLinux
+		candidates = []string{"traceroute", "/usr/sbin/traceroute", "/usr/bin/traceroute", "/bin/traceroute"}
+	}
+
+	for _, candidate := range candidates {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, flavor, nil
+		}
+	}
+	return "", flavor, &TracerouteError{Code: ErrBinaryNotFound, Message: "traceroute binary not found, install traceroute or enable useNative", Cause: exec.ErrNotFound}
+}
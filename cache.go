@@ -0,0 +1,90 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCacheTTL is how long a cached result is returned when cacheTTL is
+// not given
+const defaultCacheTTL = 30 * time.Second
+
+// resultCacheKey identifies the traces that may share a cached result
+type resultCacheKey struct {
+	host     string
+	maxHops  int
+	protocol string
+}
+
+// cachedResult is a stored result with the time it was traced
+type cachedResult struct {
+	result  TracerouteResult
+	stored  time.Time
+	expires time.Time
+}
+
+// resultCache stores the last single trace result per target
+type resultCache struct {
+	entries sync.Map // resultCacheKey -> *cachedResult
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// CacheStats reports how effective the result cache has been
+type CacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+// get returns the cached result for key marked as cached, if it has not
+// expired
+func (c *resultCache) get(key resultCacheKey) (TracerouteResult, bool) {
+	value, ok := c.entries.Load(key)
+	if ok {
+		entry := value.(*cachedResult)
+		now := time.Now()
+		if now.Before(entry.expires) {
+			c.hits.Add(1)
+			result := entry.result
+			result.Cached = true
+			result.CacheAge = now.Sub(entry.stored).Seconds()
+			return result, true
+		}
+		c.entries.CompareAndDelete(key, value)
+	}
+	c.misses.Add(1)
+	return TracerouteResult{}, false
+}
+
+// put stores result for key until ttl has passed
+func (c *resultCache) put(key resultCacheKey, result TracerouteResult, ttl time.Duration) {
+	now := time.Now()
+	c.entries.Store(key, &cachedResult{result: result, stored: now, expires: now.Add(ttl)})
+}
+
+// ClearCache drops every cached result, the hit and miss counters are kept
+func (p *TraceroutePlugin) ClearCache() {
+	p.cache.entries.Range(func(key, _ interface{}) bool {
+		p.cache.entries.Delete(key)
+		return true
+	})
+}
+
+// CacheStats returns the cache hit and miss counts and the number of
+// results that have not expired yet
+func (p *TraceroutePlugin) CacheStats() CacheStats {
+	stats := CacheStats{
+		Hits:   p.cache.hits.Load(),
+		Misses: p.cache.misses.Load(),
+	}
+	now := time.Now()
+	p.cache.entries.Range(func(_, value interface{}) bool {
+		if now.Before(value.(*cachedResult).expires) {
+			stats.Entries++
+		}
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a plugin that continues from the iteration state of p, so a
+// comparison series can run while p keeps its baseline. The results and the
+// statistics derived from them are copied, nothing is shared with p. The
+// clone does not write to the history file of p and starts with empty
+// lookup and result caches.
+func (p *TraceroutePlugin) Clone() *TraceroutePlugin {
+	cfg := p.Config
+	cfg.HistoryFile = ""
+	cfg.ParamDefaults = maps.Clone(p.Config.ParamDefaults)
+	cfg.Defaults = maps.Clone(p.Config.Defaults)
+
+	clone := &TraceroutePlugin{
+		Results:              make([]TracerouteResult, len(p.Results)),
+		StartTime:            p.StartTime,
+		IterationCount:       p.IterationCount,
+		Config:               cfg,
+		Resolver:             p.Resolver,
+		FlapHistory:          slices.Clone(p.FlapHistory),
+		slaBreachCount:       p.slaBreachCount,
+		slaBreachPeakPercent: p.slaBreachPeakPercent,
+		availability:         maps.Clone(p.availability),
+		alertStreaks:         maps.Clone(p.alertStreaks),
+		iterationWaitTime:    p.iterationWaitTime,
+	}
+	for i, r := range p.Results {
+		clone.Results[i] = cloneResult(r)
+	}
+	if p.rolling != nil {
+		clone.rolling = make(map[int][]rollingSample, len(p.rolling))
+		for hop, samples := range p.rolling {
+			copied := make([]rollingSample, len(samples))
+			for i, s := range samples {
+				s.samples = slices.Clone(s.samples)
+				copied[i] = s
+			}
+			clone.rolling[hop] = copied
+		}
+	}
+	return clone
+}
+
+// cloneResult returns a copy of r that shares no slices or maps with it
+func cloneResult(r TracerouteResult) TracerouteResult {
+	if r.Hops != nil {
+		hops := make([]HopResult, len(r.Hops))
+		for i, hop := range r.Hops {
+			hop.IPAddr = slices.Clone(hop.IPAddr)
+			hop.RTTSamples = slices.Clone(hop.RTTSamples)
+			hop.MPLSLabels = slices.Clone(hop.MPLSLabels)
+			hops[i] = hop
+		}
+		r.Hops = hops
+	}
+	if r.RawOutput != nil {
+		raw := *r.RawOutput
+		r.RawOutput = &raw
+	}
+	if r.IterationData != nil {
+		data := *r.IterationData
+		r.IterationData = &data
+	}
+	r.LoopsDetected = slices.Clone(r.LoopsDetected)
+	r.Warnings = slices.Clone(r.Warnings)
+	r.Alerts = slices.Clone(r.Alerts)
+	r.SLAViolations = slices.Clone(r.SLAViolations)
+	r.ChangedHops = slices.Clone(r.ChangedHops)
+	r.RecentFlaps = slices.Clone(r.RecentFlaps)
+	r.History = slices.Clone(r.History)
+	r.RTTAnomalies = slices.Clone(r.RTTAnomalies)
+	r.RollingStats = maps.Clone(r.RollingStats)
+	r.HopAvailability = maps.Clone(r.HopAvailability)
+	if r.Paths != nil {
+		paths := make([]TracerouteResult, len(r.Paths))
+		for i, path := range r.Paths {
+			paths[i] = cloneResult(path)
+		}
+		r.Paths = paths
+	}
+	return r
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// iterationResult returns a result with every slice, map and pointer field
+// set
+func iterationResult() TracerouteResult {
+	timestamp := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	rawOutput := "traceroute to example.com (93.184.216.34), 30 hops max\n"
+	return TracerouteResult{
+		Host: "example.com",
+		Hops: []HopResult{
+			{Hop: 1, IP: "192.168.1.1", RTT: 1.5, RTTSamples: []float64{1.2, 1.5, 1.8}, ProbesSent: 3, Status: HopStatusOK},
+			{Hop: 2, IP: "*", RTTSamples: []float64{}, ProbesSent: 3, Loss: 100, Status: HopStatusNoResponse},
+			{
+				Hop: 3, IP: "10.0.0.1", RTT: 12.25, RTTSamples: []float64{12.25}, ProbesSent: 3, Loss: 66.67,
+				Status: HopStatusPartial, MPLSLabels: []MPLSLabel{{Label: 24001, Stack: true, TTL: 1}},
+			},
+		},
+		Timestamp:   timestamp,
+		RawOutput:   &rawOutput,
+		Warnings:    []string{"hop 2 did not answer"},
+		ChangedHops: []int{3},
+		Paths: []TracerouteResult{{
+			Host:      "example.com",
+			Hops:      []HopResult{{Hop: 1, IP: "192.168.1.1", RTT: 1.5, RTTSamples: []float64{1.5}, ProbesSent: 1}},
+			Timestamp: timestamp,
+		}},
+	}
+}
+
+// iteratedPlugin returns a plugin with the state of two iterations
+func iteratedPlugin() *TraceroutePlugin {
+	first, second := iterationResult(), iterationResult()
+	first.IterationCount, second.IterationCount = 1, 2
+	first.IterationData = &IterationData{CanIterate: true, SupportsIteration: true, IterationSummary: "first"}
+
+	p := NewPlugin()
+	p.Config.MaxHistory = 10
+	p.Config.HistoryFile = "history.jsonl"
+	p.Config.Defaults = map[string]interface{}{"host": "example.com", "maxHops": 20}
+	p.Config.ParamDefaults = map[string]interface{}{"probeCount": 3, "resolveDNS": true}
+	p.Results = []TracerouteResult{first, second}
+	p.IterationCount = 2
+	p.StartTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	p.FlapHistory = []FlapEvent{{Hop: 3, PreviousIP: "10.0.0.2", CurrentIP: "10.0.0.1", Timestamp: p.StartTime}}
+	p.rolling = map[int][]rollingSample{1: {{samples: []float64{1.2, 1.5}, avg: 1.35, sent: 3}}}
+	p.availability = map[int]HopAvailability{1: {TotalProbes: 6, ResponsiveProbes: 6, Availability: 100}}
+	p.alertStreaks = map[int]int{3: 2}
+	return p
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	p := iteratedPlugin()
+	want := iteratedPlugin()
+
+	clone := p.Clone()
+	if clone.Config.HistoryFile != "" {
+		t.Errorf("clone writes to history file %q", clone.Config.HistoryFile)
+	}
+	want.Config.HistoryFile = ""
+	if !reflect.DeepEqual(clone.Results, want.Results) || !reflect.DeepEqual(clone.Config, want.Config) {
+		t.Fatal("clone does not start from the state of the original")
+	}
+
+	clone.Config.MaxHistory = 1
+	clone.Config.Defaults["maxHops"] = 5
+	clone.Config.ParamDefaults["probeCount"] = 9
+	clone.Results[0].Hops[0].IP = "192.0.2.99"
+	clone.Results[0].Hops[0].RTTSamples[0] = 99
+	clone.Results[0].Hops[2].MPLSLabels[0].Label = 1
+	clone.Results[0].Warnings[0] = "changed"
+	clone.Results[0].ChangedHops[0] = 7
+	*clone.Results[0].RawOutput = "changed"
+	clone.Results[0].IterationData.IterationSummary = "changed"
+	clone.Results[1].Paths[0].Hops[0].RTTSamples[0] = 99
+	clone.Results = append(clone.Results, iterationResult())
+	clone.FlapHistory[0].CurrentIP = "192.0.2.99"
+	clone.rolling[1][0].samples[0] = 99
+	clone.availability[1] = HopAvailability{}
+	clone.alertStreaks[3] = 10
+	clone.IterationCount = 3
+
+	want.Config.HistoryFile = "history.jsonl"
+	if !reflect.DeepEqual(p.Config, want.Config) {
+		t.Errorf("changing the clone changed the config of the original:\n got %+v\nwant %+v", p.Config, want.Config)
+	}
+	if !reflect.DeepEqual(p.Results, want.Results) {
+		t.Error("changing the clone changed the results of the original")
+	}
+	if p.IterationCount != want.IterationCount {
+		t.Errorf("got iteration count %d, want %d", p.IterationCount, want.IterationCount)
+	}
+	if !reflect.DeepEqual(p.FlapHistory, want.FlapHistory) {
+		t.Errorf("got flap history %v, want %v", p.FlapHistory, want.FlapHistory)
+	}
+	if !reflect.DeepEqual(p.rolling, want.rolling) {
+		t.Errorf("got rolling samples %v, want %v", p.rolling, want.rolling)
+	}
+	if !reflect.DeepEqual(p.availability, want.availability) {
+		t.Errorf("got availability %v, want %v", p.availability, want.availability)
+	}
+	if !reflect.DeepEqual(p.alertStreaks, want.alertStreaks) {
+		t.Errorf("got alert streaks %v, want %v", p.alertStreaks, want.alertStreaks)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Some callers encode every parameter as a string, so numbers and booleans
+// are accepted in their string form as well. An empty string counts as an
+// absent parameter.
+
+// coerceFloat64 converts a numeric parameter. ok is false when the
+// parameter is absent.
+func coerceFloat64(v interface{}) (float64, bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return v, true, nil
+	case int:
+		return float64(v), true, nil
+	case int64:
+		return float64(v), true, nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false, fmt.Errorf("must be a number, got %q", string(v))
+		}
+		return f, true, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return 0, false, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("must be a number, got %q", v)
+		}
+		return f, true, nil
+	default:
+		return 0, false, fmt.Errorf("must be a number, got %T", v)
+	}
+}
+
+// coerceInt converts a whole number parameter. ok is false when the
+// parameter is absent.
+func coerceInt(v interface{}) (int, bool, error) {
+	f, ok, err := coerceFloat64(v)
+	if err != nil || !ok {
+		return 0, ok, err
+	}
+	if f != math.Trunc(f) || math.Abs(f) > math.MaxInt32 {
+		return 0, false, fmt.Errorf("must be a whole number, got %v", f)
+	}
+	return int(f), true, nil
+}
+
+// coerceBool converts a boolean parameter, 1 and 0 are accepted as true
+// and false. ok is false when the parameter is absent.
+func coerceBool(v interface{}) (bool, bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, false, nil
+	case bool:
+		return v, true, nil
+	case float64, int, int64, json.Number:
+		f, _, err := coerceFloat64(v)
+		if err != nil || (f != 0 && f != 1) {
+			return false, false, fmt.Errorf("must be true or false, got %v", v)
+		}
+		return f == 1, true, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return false, false, nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, false, fmt.Errorf("must be true or false, got %q", v)
+		}
+		return b, true, nil
+	default:
+		return false, false, fmt.Errorf("must be true or false, got %T", v)
+	}
+}
+
+// paramReader reads typed parameters and remembers the first one that
+// could not be converted, so a block of reads needs a single error check
+type paramReader struct {
+	params map[string]interface{}
+	err    error
+}
+
+func (r *paramReader) fail(key string, err error) {
+	if r.err == nil {
+		r.err = paramError(key, err.Error())
+	}
+}
+
+func (r *paramReader) float(key string) (float64, bool) {
+	v, ok, err := coerceFloat64(r.params[key])
+	if err != nil {
+		r.fail(key, err)
+	}
+	return v, ok
+}
+
+func (r *paramReader) int(key string) (int, bool) {
+	v, ok, err := coerceInt(r.params[key])
+	if err != nil {
+		r.fail(key, err)
+	}
+	return v, ok
+}
+
+func (r *paramReader) bool(key string) (bool, bool) {
+	v, ok, err := coerceBool(r.params[key])
+	if err != nil {
+		r.fail(key, err)
+	}
+	return v, ok
+}
@@ -0,0 +1,84 @@
+package main
+
+import "sort"
+
+// PathDiff describes how the path changed between two traceroute results
+type PathDiff struct {
+	AddedHops   []HopResult   `json:"addedHops"`
+	RemovedHops []HopResult   `json:"removedHops"`
+	RTTChanges  []HopRTTDelta `json:"rttChanges"`
+	PathChanged bool          `json:"pathChanged"`
+}
+
+// HopRTTDelta is the change in average RTT of a hop present in both results
+type HopRTTDelta struct {
+	Hop    int     `json:"hop"`
+	OldRTT float64 `json:"oldRtt"`
+	NewRTT float64 `json:"newRtt"`
+	Delta  float64 `json:"delta"`
+}
+
+// CompareResults diffs two traceroute results by hop number. A hop that
+// responded in a but not in b is reported as removed, one that responded
+// only in b as added, and a hop answered by a different address in each is
+// reported as both. Hops answered by the same address in both results
+// contribute an RTT change instead.
+func CompareResults(a, b TracerouteResult) PathDiff {
+	diff := PathDiff{
+		AddedHops:   []HopResult{},
+		RemovedHops: []HopResult{},
+		RTTChanges:  []HopRTTDelta{},
+	}
+
+	before := respondingHops(a)
+	after := respondingHops(b)
+
+	for _, number := range sortedHopNumbers(before) {
+		old := before[number]
+		current, ok := after[number]
+		if !ok || current.IP != old.IP {
+			diff.RemovedHops = append(diff.RemovedHops, old)
+			continue
+		}
+		diff.RTTChanges = append(diff.RTTChanges, HopRTTDelta{
+			Hop:    number,
+			OldRTT: old.RTTAvg,
+			NewRTT: current.RTTAvg,
+			Delta:  current.RTTAvg - old.RTTAvg,
+		})
+	}
+
+	for _, number := range sortedHopNumbers(after) {
+		current := after[number]
+		if old, ok := before[number]; !ok || old.IP != current.IP {
+			diff.AddedHops = append(diff.AddedHops, current)
+		}
+	}
+
+	diff.PathChanged = len(diff.AddedHops) > 0 || len(diff.RemovedHops) > 0
+	return diff
+}
+
+// respondingHops indexes the hops that answered by hop number
+func respondingHops(r TracerouteResult) map[int]HopResult {
+	hops := make(map[int]HopResult, len(r.Hops))
+	for _, hop := range r.Hops {
+		if hop.IP == "*" {
+			continue
+		}
+		if _, exists := hops[hop.Hop]; !exists {
+			hops[hop.Hop] = hop
+		}
+	}
+	return hops
+}
+
+// sortedHopNumbers returns the hop numbers of an index in ascending order
+func sortedHopNumbers(hops map[int]HopResult) []int {
+	numbers := make([]int, 0, len(hops))
+	for number := range hops {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config holds the defaults used when a parameter is not supplied to Execute.
+// The defaults listed are those of DefaultConfig. The param tag names the
+// Execute parameter a field is the default of, the parameter schema of
+// --definition-schema is generated from it and the other tags.
+type Config struct {
+	// DefaultMaxHops is the highest hop probed, 30
+	DefaultMaxHops int `param:"maxHops" title:"Max Hops" description:"Maximum number of hops to trace" minimum:"1" maximum:"255"`
+
+	// DefaultProbeCount is the number of probes per hop, 3
+	DefaultProbeCount int `param:"probeCount" title:"Probes Per Hop" description:"Number of probes to send per hop" minimum:"1" maximum:"10"`
+
+	// DefaultTimeout bounds a whole Execute call, 0 sets no bound
+	DefaultTimeout time.Duration `param:"overallTimeout" title:"Overall Timeout" description:"Abort the trace after this many seconds (0 disables the timeout)" minimum:"0" maximum:"600"`
+
+	// DefaultProtocol is the probe protocol, empty uses the default of the
+	// traceroute binary or ICMP for native traces
+	DefaultProtocol string `param:"protocol" title:"Protocol" description:"Probe protocol to use (icmp, udp or tcp)" enum:"icmp,udp,tcp"`
+
+	// ResolveDNS looks up the hostnames of the hops, true
+	ResolveDNS bool `param:"resolveDNS" title:"Resolve DNS" description:"Resolve hop addresses to hostnames"`
+
+	// DNSParallelism is the number of concurrent reverse lookups, 8
+	DNSParallelism int `param:"dnsParallelism" title:"DNS Parallelism" description:"Maximum number of concurrent reverse DNS lookups" minimum:"1" maximum:"64"`
+
+	// NegativeCacheTTL is how long an address whose reverse lookup failed
+	// is not looked up again, 60 seconds. 0 looks every address up.
+	NegativeCacheTTL time.Duration `param:"negativeCacheTTL" title:"Negative DNS Cache TTL" description:"Seconds an address whose reverse DNS lookup failed is not looked up again (0 disables the cache)" minimum:"0" maximum:"86400"`
+
+	// MaxHistory is how many iteration results are kept, 0 keeps all
+	MaxHistory int `param:"maxHistory" title:"Max History" description:"Number of iteration results to keep in history (0 keeps all)" minimum:"0"`
+
+	// GeoIPDBPath is the GeoIP database hops are located with, empty
+	// leaves them unlocated
+	GeoIPDBPath string `param:"geoipDBPath" title:"GeoIP Database" description:"Path to a MaxMind GeoLite2-City database used to locate each hop"`
+
+	// WaitTime is the pause between probes, 0 sends them without pausing
+	WaitTime time.Duration `param:"waitTime" title:"Wait Time (seconds)" description:"Pause in seconds between probes, useful on rate-limited routers. In iteration mode the value is kept for later iterations" minimum:"0" maximum:"10"`
+
+	// RollingWindow is how many iterations per hop the rolling statistics
+	// cover, 10
+	RollingWindow int `param:"rollingWindow" title:"Rolling Window" description:"Number of recent iterations used for rolling statistics" minimum:"1" maximum:"1000"`
+
+	// HistoryFile is the file iteration results are appended to and
+	// restored from, empty keeps them in memory only
+	HistoryFile string `param:"historyFile" title:"History File" description:"JSON lines file each iteration result is appended to, the iteration history is restored from it after a restart"`
+
+	// MaxConcurrency is how many targets ExecuteBatch traces at once when
+	// it is not given a parallelism, 4
+	MaxConcurrency int
+
+	// RemoteAgentURL is the base URL of a plugin serving the HTTP API near
+	// the destination, LookupPath has it trace the return path
+	RemoteAgentURL string
+
+	// FlapThreshold is how many times a hop must change address within the
+	// stored iterations before its flaps are reported, 0 reports every flap
+	FlapThreshold int
+
+	// OnHopDiscovered, when set, is called with every hop of a native trace
+	// as soon as it has been probed and its hostname resolved, while the
+	// trace is still running. It is called from the goroutine handling the
+	// hop, callers must do their own synchronization.
+	OnHopDiscovered func(hop HopResult)
+
+	// ParamDefaults holds the parameter defaults of the plugin definition,
+	// see NewPluginFromDefinition
+	ParamDefaults map[string]interface{}
+
+	// Defaults holds parameter values used when Execute is not passed them,
+	// such as those of a --config file. Unlike ParamDefaults they apply to
+	// every parameter, the host included, and take precedence over them.
+	Defaults map[string]interface{}
+}
+
+// DefaultConfig returns the configuration of a plugin created by NewPlugin
+// without options. Settings left at their zero value are documented on
+// Config, so a single field can be changed before NewPluginWithConfig:
+//
+//	cfg := DefaultConfig()
+//	cfg.DefaultMaxHops = 15
+//	p := NewPluginWithConfig(cfg)
+func DefaultConfig() Config {
+	return Config{
+		DefaultMaxHops:    30,
+		DefaultProbeCount: 3,
+		ResolveDNS:        true,
+		DNSParallelism:    defaultDNSParallelism,
+		NegativeCacheTTL:  defaultNegativeCacheTTL,
+		RollingWindow:     defaultRollingWindow,
+		MaxConcurrency:    defaultBatchParallelism,
+	}
+}
+
+// NewPluginWithConfig creates a new plugin instance using cfg for any
+// parameter that is not passed to Execute. If cfg.HistoryFile exists the
+// iteration history is restored from it.
+func NewPluginWithConfig(cfg Config) *TraceroutePlugin {
+	p := &TraceroutePlugin{
+		StartTime: time.Now(),
+		Results:   []TracerouteResult{},
+		Config:    cfg,
+	}
+	if cfg.HistoryFile != "" {
+		if err := p.restoreHistory(cfg.HistoryFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	return p
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultConfigMatchesNewPlugin(t *testing.T) {
+	got := DefaultConfig()
+	if want := NewPlugin().Config; !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultConfig() = %+v, NewPlugin() uses %+v", got, want)
+	}
+
+	// The defaults documented on Config
+	want := Config{
+		DefaultMaxHops:    30,
+		DefaultProbeCount: 3,
+		ResolveDNS:        true,
+		DNSParallelism:    8,
+		NegativeCacheTTL:  60 * time.Second,
+		RollingWindow:     10,
+		MaxConcurrency:    4,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultConfig() = %+v, want the documented %+v", got, want)
+	}
+
+	cfg := DefaultConfig()
+	cfg.DefaultMaxHops = 15
+	if got, want := NewPluginWithConfig(cfg).Config, NewPlugin(WithMaxHops(15)).Config; !reflect.DeepEqual(got, want) {
+		t.Errorf("changing DefaultConfig() gives %+v, the option gives %+v", got, want)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want map[string]interface{}
+	}{
+		{"config.yaml", "# team defaults\nprotocol: tcp\nport: 443\nsla:\n  maxRttMs: 150.5\n", map[string]interface{}{
+			"protocol": "tcp",
+			"port":     443,
+			"sla":      map[string]interface{}{"maxRttMs": 150.5},
+		}},
+		{"config.yml", "", map[string]interface{}{}},
+		{"config.json", `{"protocol": "tcp", "port": 443, "sla": {"maxRttMs": 150.5}}`, map[string]interface{}{
+			"protocol": "tcp",
+			"port":     443.0,
+			"sla":      map[string]interface{}{"maxRttMs": 150.5},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(path, []byte(tt.doc), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadConfigFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: [443\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfigFile(path); err == nil {
+		t.Error("malformed YAML gave no error")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile reads the parameter defaults of a --config file. Files
+// ending in .yaml or .yml are YAML, any other file is JSON. The top level
+// must be an object keyed by parameter.
+func loadConfigFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var defaults map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &defaults)
+	default:
+		err = json.Unmarshal(data, &defaults)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("malformed config file %s: %v", path, err)
+	}
+	if defaults == nil {
+		defaults = map[string]interface{}{}
+	}
+	return defaults, nil
+}
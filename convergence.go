@@ -0,0 +1,62 @@
+package main
+
+import "math"
+
+const (
+	// defaultConvergenceWindow is how many iterations must agree before a
+	// trace is considered converged
+	defaultConvergenceWindow = 5
+
+	// defaultRTTTolerance is the RTT variation, in percent of the mean,
+	// still considered stable
+	defaultRTTTolerance = 10.0
+)
+
+// stableIterations returns how many of the most recent results share the
+// same path with every hop RTT within tolerance percent of its mean over
+// those results. The latest result alone always counts as stable.
+func stableIterations(results []TracerouteResult, tolerance float64) int {
+	if len(results) == 0 {
+		return 0
+	}
+	n := 1
+	for n < len(results) && pathStable(results[len(results)-n-1:], tolerance) {
+		n++
+	}
+	return n
+}
+
+// pathStable reports whether every result has the same responding hops
+// with RTTs within tolerance percent of their mean
+func pathStable(results []TracerouteResult, tolerance float64) bool {
+	paths := make([]map[int]HopResult, len(results))
+	for i, r := range results {
+		paths[i] = respondingHops(r)
+	}
+
+	reference := paths[len(paths)-1]
+	for _, hops := range paths {
+		if len(hops) != len(reference) {
+			return false
+		}
+		for number, hop := range hops {
+			if ref, ok := reference[number]; !ok || ref.IP != hop.IP {
+				return false
+			}
+		}
+	}
+
+	for number := range reference {
+		var sum float64
+		for _, hops := range paths {
+			sum += hops[number].RTTAvg
+		}
+		mean := sum / float64(len(paths))
+		for _, hops := range paths {
+			if math.Abs(hops[number].RTTAvg-mean) > mean*tolerance/100 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// definitionPath is the plugin definition read by the command line
+const definitionPath = "plugin.json"
+
+// parameterDefinition is a parameter declared in plugin.json
+type parameterDefinition struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Required    bool        `json:"required"`
+	Min         *float64    `json:"min"`
+	Max         *float64    `json:"max"`
+	Step        *float64    `json:"step"`
+	Options     []string    `json:"options"`
+}
+
+// pluginDefinition is the part of plugin.json used at runtime
+type pluginDefinition struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Parameters  []parameterDefinition `json:"parameters"`
+}
+
+// loadDefinition reads and checks the plugin definition at path
+func loadDefinition(path string) (pluginDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pluginDefinition{}, err
+	}
+	var definition pluginDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return pluginDefinition{}, fmt.Errorf("malformed plugin definition %s: %v", path, err)
+	}
+	for _, param := range definition.Parameters {
+		if param.ID == "" {
+			return pluginDefinition{}, fmt.Errorf("malformed plugin definition %s: parameter without an id", path)
+		}
+	}
+	return definition, nil
+}
+
+// LoadParamDefaults returns the default of every parameter declared in the
+// plugin definition at path
+func LoadParamDefaults(path string) (map[string]interface{}, error) {
+	definition, err := loadDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := map[string]interface{}{}
+	for _, param := range definition.Parameters {
+		if param.Default != nil {
+			defaults[param.ID] = param.Default
+		}
+	}
+	return defaults, nil
+}
+
+// NewPluginFromDefinition creates a plugin whose defaults are those
+// declared in the plugin definition at path. Defaults backed by a Config
+// field are stored there, the others fill in absent Execute parameters.
+func NewPluginFromDefinition(path string) (*TraceroutePlugin, error) {
+	defaults, err := LoadParamDefaults(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+	in := paramReader{params: defaults}
+	if v, ok := in.int("maxHops"); ok {
+		cfg.DefaultMaxHops = v
+	}
+	if v, ok := in.int("probeCount"); ok {
+		cfg.DefaultProbeCount = v
+	}
+	if v, ok := in.bool("resolveDNS"); ok {
+		cfg.ResolveDNS = v
+	}
+	if v, ok := in.int("dnsParallelism"); ok {
+		cfg.DNSParallelism = v
+	}
+	if v, ok := in.float("overallTimeout"); ok {
+		cfg.DefaultTimeout = time.Duration(v * float64(time.Second))
+	}
+	if v, ok := in.float("negativeCacheTTL"); ok {
+		cfg.NegativeCacheTTL = time.Duration(v * float64(time.Second))
+	}
+	if v, ok := in.int("maxHistory"); ok {
+		cfg.MaxHistory = v
+	}
+	if v, ok := in.float("waitTime"); ok {
+		cfg.WaitTime = time.Duration(v * float64(time.Second))
+	}
+	if v, ok := in.int("rollingWindow"); ok {
+		cfg.RollingWindow = v
+	}
+	if v, ok := defaults["geoipDBPath"].(string); ok {
+		cfg.GeoIPDBPath = v
+	}
+	if v, ok := defaults["historyFile"].(string); ok {
+		cfg.HistoryFile = v
+	}
+	if in.err != nil {
+		return nil, fmt.Errorf("malformed plugin definition %s: default %v", path, in.err)
+	}
+	cfg.ParamDefaults = defaults
+	return NewPluginWithConfig(cfg), nil
+}
+
+// configParams are the parameters whose defaults live in Config fields, or
+// that have no single default: the host is required, the native
+// implementation and the system binary probe with different protocols and
+// the minimum packet size depends on the address family
+var configParams = map[string]bool{
+	"host":             true,
+	"protocol":         true,
+	"packetSize":       true,
+	"maxHops":          true,
+	"probeCount":       true,
+	"resolveDNS":       true,
+	"dnsParallelism":   true,
+	"negativeCacheTTL": true,
+	"overallTimeout":   true,
+	"maxHistory":       true,
+	"waitTime":         true,
+	"rollingWindow":    true,
+	"geoipDBPath":      true,
+	"historyFile":      true,
+}
+
+// withParamDefaults returns params with absent parameters set to the
+// configured defaults, or else their definition defaults
+func (p *TraceroutePlugin) withParamDefaults(params map[string]interface{}) map[string]interface{} {
+	if len(p.Config.ParamDefaults) == 0 && len(p.Config.Defaults) == 0 {
+		return params
+	}
+	merged := make(map[string]interface{}, len(params)+len(p.Config.ParamDefaults)+len(p.Config.Defaults))
+	for k, v := range p.Config.ParamDefaults {
+		if !configParams[k] {
+			merged[k] = v
+		}
+	}
+	for k, v := range p.Config.Defaults {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+	return merged
+}
+
+// paramsSchema returns a JSON Schema (draft-07) describing the params
+// object accepted by Execute. Parameters backed by a Config field are
+// generated from its tags, see configSchemaProperties, the others from
+// their declarations in the plugin definition at path.
+func paramsSchema(path string) (map[string]interface{}, error) {
+	definition, err := loadDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := configSchemaProperties(DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	required := []string{}
+	for _, param := range definition.Parameters {
+		if param.Required {
+			required = append(required, param.ID)
+		}
+		if _, ok := properties[param.ID]; ok {
+			continue
+		}
+		property := map[string]interface{}{
+			"title":       param.Name,
+			"description": param.Description,
+		}
+		switch param.Type {
+		case "number":
+			// Whole number steps only accept integers
+			property["type"] = "number"
+			if param.Step != nil && *param.Step == 1 {
+				property["type"] = "integer"
+			}
+			if param.Min != nil {
+				property["minimum"] = *param.Min
+			}
+			if param.Max != nil {
+				property["maximum"] = *param.Max
+			}
+		case "boolean":
+			property["type"] = "boolean"
+		case "object":
+			property["type"] = "object"
+		case "select":
+			property["type"] = "string"
+			property["enum"] = param.Options
+		default:
+			property["type"] = "string"
+		}
+		if param.Default != nil {
+			property["default"] = param.Default
+		}
+		properties[param.ID] = property
+	}
+
+	return map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       definition.Name + " parameters",
+		"description": definition.Description,
+		"type":        "object",
+		"properties":  properties,
+		"required":    required,
+	}, nil
+}
+
+// configSchemaProperties returns the JSON Schema properties of the
+// parameters backed by Config fields. Fields with a param tag are
+// described by their title, description, minimum, maximum and enum tags
+// and default to their value in cfg. Durations are given in seconds, empty
+// strings have no default.
+func configSchemaProperties(cfg Config) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	value := reflect.ValueOf(cfg)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		id := field.Tag.Get("param")
+		if id == "" {
+			continue
+		}
+		property := map[string]interface{}{
+			"title":       field.Tag.Get("title"),
+			"description": field.Tag.Get("description"),
+		}
+
+		v := value.Field(i)
+		switch {
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			property["type"] = "number"
+			property["default"] = time.Duration(v.Int()).Seconds()
+		case v.Kind() == reflect.Int:
+			property["type"] = "integer"
+			property["default"] = v.Int()
+		case v.Kind() == reflect.Float64:
+			property["type"] = "number"
+			property["default"] = v.Float()
+		case v.Kind() == reflect.Bool:
+			property["type"] = "boolean"
+			property["default"] = v.Bool()
+		case v.Kind() == reflect.String:
+			property["type"] = "string"
+			if v.String() != "" {
+				property["default"] = v.String()
+			}
+		default:
+			return nil, fmt.Errorf("parameter %s: Config.%s has unsupported type %s", id, field.Name, field.Type)
+		}
+
+		for _, bound := range []string{"minimum", "maximum"} {
+			tag := field.Tag.Get(bound)
+			if tag == "" {
+				continue
+			}
+			n, err := strconv.ParseFloat(tag, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: invalid %s tag of Config.%s: %v", id, bound, field.Name, err)
+			}
+			property[bound] = n
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property["enum"] = strings.Split(enum, ",")
+		}
+		properties[id] = property
+	}
+	return properties, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigSchemaProperties(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DefaultTimeout = 90 * time.Second
+	cfg.GeoIPDBPath = "/var/lib/GeoLite2-City.mmdb"
+	properties, err := configSchemaProperties(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tagged := 0
+	for i := 0; i < reflect.TypeOf(cfg).NumField(); i++ {
+		if reflect.TypeOf(cfg).Field(i).Tag.Get("param") != "" {
+			tagged++
+		}
+	}
+	if len(properties) != tagged {
+		t.Errorf("got %d properties, want one per tagged Config field, %d", len(properties), tagged)
+	}
+
+	tests := map[string]map[string]interface{}{
+		"maxHops": {
+			"title": "Max Hops", "description": "Maximum number of hops to trace",
+			"type": "integer", "default": int64(30), "minimum": 1.0, "maximum": 255.0,
+		},
+		"overallTimeout": {
+			"title": "Overall Timeout", "description": "Abort the trace after this many seconds (0 disables the timeout)",
+			"type": "number", "default": 90.0, "minimum": 0.0, "maximum": 600.0,
+		},
+		"resolveDNS": {
+			"title": "Resolve DNS", "description": "Resolve hop addresses to hostnames",
+			"type": "boolean", "default": true,
+		},
+		"protocol": {
+			"title": "Protocol", "description": "Probe protocol to use (icmp, udp or tcp)",
+			"type": "string", "enum": []string{"icmp", "udp", "tcp"},
+		},
+		"geoipDBPath": {
+			"title": "GeoIP Database", "description": "Path to a MaxMind GeoLite2-City database used to locate each hop",
+			"type": "string", "default": "/var/lib/GeoLite2-City.mmdb",
+		},
+	}
+	for id, want := range tests {
+		if got := properties[id]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", id, got, want)
+		}
+	}
+}
+
+// TestParamsSchemaMatchesDefinition checks that the parameters generated
+// from Config agree with their declarations in plugin.json
+func TestParamsSchemaMatchesDefinition(t *testing.T) {
+	definition, err := loadDefinition(definitionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared := map[string]parameterDefinition{}
+	for _, param := range definition.Parameters {
+		declared[param.ID] = param
+	}
+	properties, err := configSchemaProperties(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, p := range properties {
+		property := p.(map[string]interface{})
+		param, ok := declared[id]
+		if !ok {
+			t.Errorf("%s is not declared in %s", id, definitionPath)
+			continue
+		}
+		if property["title"] != param.Name || property["description"] != param.Description {
+			t.Errorf("%s: got title %q and description %q, %s declares %q and %q",
+				id, property["title"], property["description"], definitionPath, param.Name, param.Description)
+		}
+		for bound, limit := range map[string]*float64{"minimum": param.Min, "maximum": param.Max} {
+			var want interface{}
+			if limit != nil {
+				want = *limit
+			}
+			if got := property[bound]; got != want {
+				t.Errorf("%s: got %s %v, %s declares %v", id, bound, got, definitionPath, want)
+			}
+		}
+		if enum, ok := property["enum"]; ok && !reflect.DeepEqual(enum, param.Options) {
+			t.Errorf("%s: got options %v, %s declares %v", id, enum, definitionPath, param.Options)
+		}
+		if def, ok := property["default"]; ok && fmt.Sprint(def) != fmt.Sprint(param.Default) {
+			t.Errorf("%s: got default %v, %s declares %v", id, def, definitionPath, param.Default)
+		}
+	}
+
+	schema, err := paramsSchema(definitionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(schema["properties"].(map[string]interface{})); got != len(definition.Parameters) {
+		t.Errorf("got %d properties, %s declares %d parameters", got, definitionPath, len(definition.Parameters))
+	}
+	if got := schema["required"]; !reflect.DeepEqual(got, []string{"host"}) {
+		t.Errorf("got required %v, want [host]", got)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDNSParallelism is the number of concurrent reverse lookups
+	defaultDNSParallelism = 8
+
+	// defaultDNSTimeout bounds each individual reverse lookup
+	defaultDNSTimeout = 2 * time.Second
+
+	// defaultNegativeCacheTTL is how long an address without a hostname
+	// is not looked up again
+	defaultNegativeCacheTTL = 60 * time.Second
+)
+
+// privateDNSBlocks are the address ranges that rarely have meaningful PTR
+// records, see skipPrivateDNS
+var privateDNSBlocks = func() []*net.IPNet {
+	var blocks []*net.IPNet
+	for _, cidr := range []string{
+		"10.0.0.0/8",     // RFC 1918
+		"172.16.0.0/12",  // RFC 1918
+		"192.168.0.0/16", // RFC 1918
+		"127.0.0.0/8",    // RFC 1122 loopback
+		"169.254.0.0/16", // RFC 3927 link-local
+		"fc00::/7",       // RFC 4193 unique local
+	} {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}()
+
+// isPrivateIP reports whether ip is in one of the privateDNSBlocks
+func isPrivateIP(ip net.IP) bool {
+	for _, block := range privateDNSBlocks {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// negativeDNSCache remembers the addresses whose reverse lookup failed, so
+// infrastructure addresses without PTR records, such as carrier-grade NAT
+// space, do not cost a DNS round-trip on every trace. Entries apply to
+// every DNS server.
+type negativeDNSCache struct {
+	entries sync.Map // IP address -> time.Time the lookup failed
+}
+
+// unresolvable reports whether the lookup of ip failed within the last ttl
+func (c *negativeDNSCache) unresolvable(ip string, ttl time.Duration) bool {
+	if c == nil {
+		return false
+	}
+	value, ok := c.entries.Load(ip)
+	if !ok {
+		return false
+	}
+	if time.Since(value.(time.Time)) < ttl {
+		return true
+	}
+	c.entries.CompareAndDelete(ip, value)
+	return false
+}
+
+// add records that the lookup of ip failed
+func (c *negativeDNSCache) add(ip string) {
+	if c != nil {
+		c.entries.Store(ip, time.Now())
+	}
+}
+
+// newDNSResolver returns a resolver that sends every query to server, an
+// ip:port address
+func newDNSResolver(server string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// validDNSServer reports whether server is an ip:port address
+func validDNSServer(server string) bool {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil || net.ParseIP(host) == nil {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// resolveHostnames fills in the hostname of every responding hop using
+// concurrent reverse DNS lookups, asking resolver first when it is not nil.
+// Hops that cannot be resolved keep their IP address as the name. cache
+// holds the *dnsEntry of addresses already looked up during the trace and
+// may be nil, as may negative, whose entries are used for negativeTTL.
+// With skipPrivate, hops in the private ranges
+// are named by their IP address without a lookup.
+func resolveHostnames(ctx context.Context, hops []HopResult, parallelism int, timeout time.Duration, resolver *net.Resolver, cache *sync.Map, negative *negativeDNSCache, negativeTTL time.Duration, skipPrivate bool) {
+	if parallelism < 1 {
+		parallelism = defaultDNSParallelism
+	}
+
+	// Routers often show up more than once in a trace, only look them up once
+	if cache == nil {
+		cache = &sync.Map{}
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallelism)
+
+	for i := range hops {
+		if hops[i].IP == "*" {
+			continue
+		}
+		if skipPrivate && isPrivateIP(net.ParseIP(hops[i].IP)) {
+			hops[i].Name = hops[i].IP
+			continue
+		}
+
+		wg.Add(1)
+		go func(hop *HopResult) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			entry, _ := cache.LoadOrStore(hop.IP, &dnsEntry{})
+			hop.Name = entry.(*dnsEntry).lookup(ctx, hop.IP, resolver, timeout, negative, negativeTTL)
+		}(&hops[i])
+	}
+
+	wg.Wait()
+}
+
+// dnsEntry resolves an address exactly once and shares the answer
+type dnsEntry struct {
+	once sync.Once
+	name string
+}
+
+// lookup returns the hostname of ip, or ip itself when it has none. When
+// resolver fails, for example because it does not know the address or does
+// not answer in time, the system resolver is asked. Each gets the full
+// timeout. Addresses whose lookup failed within negativeTTL according to
+// negative are not looked up and those that fail are added to it.
+func (e *dnsEntry) lookup(ctx context.Context, ip string, resolver *net.Resolver, timeout time.Duration, negative *negativeDNSCache, negativeTTL time.Duration) string {
+	e.once.Do(func() {
+		e.name = ip
+		if negative.unresolvable(ip, negativeTTL) {
+			return
+		}
+		lookupAddr := func(resolver *net.Resolver) ([]string, error) {
+			lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return resolver.LookupAddr(lookupCtx, ip)
+		}
+
+		var names []string
+		var err error
+		if resolver != nil {
+			names, err = lookupAddr(resolver)
+		}
+		if resolver == nil || err != nil && ctx.Err() == nil {
+			names, err = lookupAddr(net.DefaultResolver)
+		}
+		if err == nil && len(names) > 0 {
+			e.name = strings.TrimSuffix(names[0], ".")
+		} else if ctx.Err() == nil {
+			negative.add(ip)
+		}
+	})
+	return e.name
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dotEdgeColors tells apart the edges only seen in some iterations, by the
+// first iteration that took them
+var dotEdgeColors = []string{"blue", "orange", "purple", "brown", "cyan4", "deeppink", "gold3", "gray40"}
+
+// dotEdge is a link between two consecutive hops of a path
+type dotEdge struct {
+	from, to string
+}
+
+// ExportDOT writes the paths of one or more results, for example the stored
+// iterations, as a Graphviz DOT directed graph. Nodes are hop addresses
+// labeled with hostname and AS organization and edges carry the RTT of the
+// hop they lead to, as last measured. Edges taken by every result are
+// solid, the others are dashed and colored by the first result that took
+// them. The final hop of the last result is green when the destination was
+// reached and red otherwise.
+func ExportDOT(w io.Writer, results ...TracerouteResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	last := results[len(results)-1]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph traceroute {\n")
+	fmt.Fprintf(&b, "  rankdir=LR;\n")
+	fmt.Fprintf(&b, "  label=%s;\n", dotQuote("traceroute to "+last.Host))
+	fmt.Fprintf(&b, "  node [shape=box];\n")
+
+	source := "source"
+	if last.SourceAddress != "" {
+		source = last.SourceAddress
+	}
+	fmt.Fprintf(&b, "  %s [label=%s, shape=ellipse];\n", dotQuote("source"), dotQuote(source))
+
+	// Collect nodes and edges in order of first appearance
+	var nodes []string
+	labels := map[string]string{}
+	var edges []dotEdge
+	edgeRTT := map[dotEdge]float64{}
+	edgeSeen := map[dotEdge]int{}
+	edgeFirst := map[dotEdge]int{}
+	for i, r := range results {
+		from := "source"
+		seen := map[dotEdge]bool{}
+		for _, hop := range r.Hops {
+			id := dotNodeID(hop)
+			if _, ok := labels[id]; !ok {
+				nodes = append(nodes, id)
+			}
+			// Keep the most detailed label, lookups may fail in some results
+			if label := dotNodeLabel(hop); len(label) > len(labels[id]) {
+				labels[id] = label
+			}
+
+			edge := dotEdge{from, id}
+			if _, ok := edgeFirst[edge]; !ok {
+				edges = append(edges, edge)
+				edgeFirst[edge] = i
+			}
+			if !seen[edge] {
+				seen[edge] = true
+				edgeSeen[edge]++
+			}
+			if hop.IP != "*" {
+				edgeRTT[edge] = hop.RTTAvg
+			}
+			from = id
+		}
+	}
+
+	finalNode := ""
+	if len(last.Hops) > 0 {
+		finalNode = dotNodeID(last.Hops[len(last.Hops)-1])
+	}
+	for _, id := range nodes {
+		attrs := "label=" + dotQuote(labels[id])
+		if id == finalNode {
+			color := "red"
+			if last.DestinationReached {
+				color = "green"
+			}
+			attrs += ", style=filled, fillcolor=" + color
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(id), attrs)
+	}
+
+	for _, edge := range edges {
+		var attrs []string
+		if rtt, ok := edgeRTT[edge]; ok {
+			attrs = append(attrs, "label="+dotQuote(formatFloat(rtt)+" ms"))
+		}
+		if edgeSeen[edge] < len(results) {
+			attrs = append(attrs, "style=dashed", "color="+dotEdgeColors[edgeFirst[edge]%len(dotEdgeColors)])
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.from), dotQuote(edge.to), strings.Join(attrs, ", "))
+	}
+	fmt.Fprintf(&b, "}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotNodeID identifies a hop in the graph. Hops that did not answer are
+// distinct per TTL as they may be different routers.
+func dotNodeID(hop HopResult) string {
+	if hop.IP == "*" {
+		return "* hop " + strconv.Itoa(hop.Hop)
+	}
+	return hop.IP
+}
+
+// dotNodeLabel shows the address, hostname and AS organization of a hop
+func dotNodeLabel(hop HopResult) string {
+	if hop.IP == "*" {
+		return "*"
+	}
+	lines := []string{hop.IP}
+	if hop.Name != "" && hop.Name != hop.IP {
+		lines = append(lines, hop.Name)
+	}
+	if hop.ASN != 0 {
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("AS%d %s", hop.ASN, hop.ASNOrg)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dotQuote returns s as a DOT quoted string, newlines become line breaks
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
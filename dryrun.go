@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// describeTrace returns what a trace with opts would do without sending
+// anything: the shell-quoted traceroute command, or the socket parameters
+// of the native implementation
+func describeTrace(opts traceOptions, useNative bool) (string, error) {
+	if useNative {
+		return describeNative(opts), nil
+	}
+
+	binary, flavor, err := detectTracerouteBinary()
+	if err != nil {
+		// Still show the command, it is what would run once installed
+		binary = "traceroute"
+		if flavor == FlavorWindows {
+			binary = "tracert"
+		}
+	}
+	binary, args, _, err := tracerouteCommand(binary, flavor, opts)
+	if err != nil {
+		return "", err
+	}
+	return shellJoin(append([]string{binary}, args...)), nil
+}
+
+// describeNative lists the socket parameters the native implementation
+// would probe with
+func describeNative(opts traceOptions) string {
+	protocol := opts.protocol
+	if protocol == "" {
+		protocol = "icmp"
+	}
+	source := "default"
+	if opts.source != nil {
+		source = opts.source.String()
+	}
+
+	parts := []string{
+		fmt.Sprintf("native %s probes from %s to %s", protocol, source, opts.target),
+		fmt.Sprintf("ttl %d-%d", opts.firstHop, opts.maxHops),
+		fmt.Sprintf("%d probes per hop", opts.probeCount),
+		fmt.Sprintf("packet size %d", opts.packetSize),
+	}
+	if opts.sourceInterface != "" {
+		parts = append(parts, "interface "+opts.sourceInterface)
+	}
+	if protocol != "icmp" {
+		parts = append(parts, fmt.Sprintf("port %d", opts.port))
+		if opts.sourcePort != 0 {
+			parts = append(parts, fmt.Sprintf("source port %d", opts.sourcePort))
+		}
+	}
+	if opts.paris {
+		flow := fmt.Sprintf("paris flow %d", opts.flowID)
+		if protocol == "udp" {
+			flow += fmt.Sprintf(" (source port %d)", parisSourcePort(opts.flowID))
+		}
+		parts = append(parts, flow)
+	}
+	if opts.tos != 0 {
+		parts = append(parts, fmt.Sprintf("tos %d", opts.tos))
+	}
+	if opts.dontFragment {
+		parts = append(parts, "don't fragment")
+	}
+	if opts.waitTime > 0 {
+		parts = append(parts, fmt.Sprintf("wait %v", opts.waitTime))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// shellJoin quotes each argument for a POSIX shell where needed and joins
+// them into a command line that can be pasted into a terminal
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.IndexFunc(arg, needsShellQuote) >= 0 {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func needsShellQuote(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("-_./:=@%+,", r)
+}
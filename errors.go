@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// ErrorCode classifies a TracerouteError
+type ErrorCode string
+
+// Error codes reported in TracerouteError.Code
+const (
+	ErrMissingHost      ErrorCode = "MISSING_HOST"
+	ErrInvalidParam     ErrorCode = "INVALID_PARAM"
+	ErrCommandFailed    ErrorCode = "COMMAND_FAILED"
+	ErrPermissionDenied ErrorCode = "PERMISSION_DENIED"
+	ErrTimeout          ErrorCode = "TIMEOUT"
+	ErrCancelled        ErrorCode = "CANCELLED"
+	ErrDNSFailed        ErrorCode = "DNS_FAILED"
+	ErrBinaryNotFound   ErrorCode = "BINARY_NOT_FOUND"
+)
+
+// TracerouteError is the error returned by Execute. Message is the full
+// description including the cause, Cause is the underlying error if any,
+// a ValidationError naming the parameter for invalid parameters.
+type TracerouteError struct {
+	Code    ErrorCode
+	Message string
+	Cause   error
+
+	// Suggestions lists remedies the user can try, if any
+	Suggestions []string
+}
+
+func (e *TracerouteError) Error() string {
+	return e.Message
+}
+
+func (e *TracerouteError) Unwrap() error {
+	return e.Cause
+}
+
+// paramError reports an invalid parameter
+func paramError(field, message string) *TracerouteError {
+	cause := &ValidationError{Field: field, Message: message}
+	code := ErrInvalidParam
+	if field == "host" && message == "is required" {
+		code = ErrMissingHost
+	}
+	return &TracerouteError{Code: code, Message: cause.Error(), Cause: cause}
+}
+
+// permissionError reports that the raw sockets of native traces can not be
+// opened, which is common in containers
+func permissionError(err error) *TracerouteError {
+	suggestions := []string{"run as root", "add CAP_NET_RAW capability"}
+	if runtime.GOOS == "windows" {
+		suggestions = []string{"run as Administrator"}
+	}
+	suggestions = append(suggestions, "set useNative to false to fall back to the system traceroute")
+	return &TracerouteError{
+		Code:        ErrPermissionDenied,
+		Message:     fmt.Sprintf("not permitted to open raw sockets for native traces: %v", err),
+		Cause:       err,
+		Suggestions: suggestions,
+	}
+}
+
+// classifyError turns err into a TracerouteError. Errors that are already
+// classified keep their code, timeouts, cancellation and permission errors
+// are recognized and anything else gets the fallback code.
+func classifyError(err error, fallback ErrorCode) error {
+	if err == nil {
+		return nil
+	}
+	var traceErr *TracerouteError
+	if errors.As(err, &traceErr) {
+		return err
+	}
+
+	code := fallback
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		code = ErrInvalidParam
+	case errors.Is(err, context.DeadlineExceeded):
+		code = ErrTimeout
+	case errors.Is(err, context.Canceled):
+		code = ErrCancelled
+	case errors.Is(err, os.ErrPermission):
+		code = ErrPermissionDenied
+	}
+	return &TracerouteError{Code: code, Message: err.Error(), Cause: err}
+}
+
+// errorCode returns the code of a TracerouteError in err's chain, or ""
+func errorCode(err error) ErrorCode {
+	var traceErr *TracerouteError
+	if errors.As(err, &traceErr) {
+		return traceErr.Code
+	}
+	return ""
+}
+
+// exitCode is the process exit status used for err on the command line
+func exitCode(err error) int {
+	switch errorCode(err) {
+	case ErrMissingHost, ErrInvalidParam:
+		return 2
+	case ErrPermissionDenied:
+		return 3
+	case ErrTimeout:
+		return 4
+	case ErrBinaryNotFound:
+		return 5
+	default:
+		return 1
+	}
+}
+
+// errorReport describes err for the command line output, with its code,
+// suggestions and the invalid parameter when known
+func errorReport(err error) map[string]interface{} {
+	report := map[string]interface{}{"error": err.Error()}
+	var traceErr *TracerouteError
+	if errors.As(err, &traceErr) {
+		report["code"] = traceErr.Code
+		if len(traceErr.Suggestions) > 0 {
+			report["suggestions"] = traceErr.Suggestions
+		}
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		report["field"] = validationErr.Field
+	}
+	return report
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ExportJSON writes the result as a single line of JSON, or indented with
+// two spaces when pretty is set. The timestamp is RFC 3339 with
+// nanoseconds, the elapsed time a duration string such as "1.5s" and
+// rawOutput is left out when it was not kept.
+func (r TracerouteResult) ExportJSON(w io.Writer, pretty bool) error {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(r)
+}
+
+// ParseJSON reads a result written by ExportJSON
+func ParseJSON(r io.Reader) (TracerouteResult, error) {
+	var result TracerouteResult
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return TracerouteResult{}, fmt.Errorf("failed to parse traceroute result: %v", err)
+	}
+	return result, nil
+}
+
+// defaultInfluxMeasurement is the measurement used by ExportInfluxDB when
+// none is given
+const defaultInfluxMeasurement = "traceroute"
+
+// ExportInfluxDB returns the result in InfluxDB line protocol, one line per
+// hop timestamped with the result's timestamp in nanoseconds. Hops that did
+// not answer have no rtt field, so they don't show up as 0 ms samples.
+func (r TracerouteResult) ExportInfluxDB(measurement string) string {
+	if measurement == "" {
+		measurement = defaultInfluxMeasurement
+	}
+
+	var b strings.Builder
+	for _, hop := range r.Hops {
+		fmt.Fprintf(&b, "%s,host=%s,hop=%d,hop_ip=%s ",
+			influxMeasurementEscaper.Replace(measurement),
+			influxTagEscaper.Replace(r.Host),
+			hop.Hop,
+			influxTagEscaper.Replace(hop.IP))
+		if len(hop.RTTSamples) > 0 {
+			fmt.Fprintf(&b, "rtt=%s,", formatFloat(hop.RTT))
+		}
+		fmt.Fprintf(&b, "loss=%s,asn=%di %d\n", formatFloat(hop.Loss), hop.ASN, r.Timestamp.UnixNano())
+	}
+	return b.String()
+}
+
+// Line protocol escaping: measurements escape commas and spaces, tag keys
+// and values additionally escape equals signs
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+// ExportCSV writes one row per hop with a header row. When any hop has more
+// than one RTT sample the RTT column holds the average and rttMin and rttMax
+// columns are added.
+func (r TracerouteResult) ExportCSV(w io.Writer) error {
+	multiSample := false
+	for _, hop := range r.Hops {
+		if len(hop.RTTSamples) > 1 {
+			multiSample = true
+			break
+		}
+	}
+
+	header := []string{"iterationCount", "timestamp", "hop", "ip", "name", "rtt", "loss", "asn", "country"}
+	if multiSample {
+		header = append(header, "rttMin", "rttMax")
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, hop := range r.Hops {
+		rtt := hop.RTT
+		if multiSample && len(hop.RTTSamples) > 0 {
+			rtt = hop.RTTAvg
+		}
+
+		row := []string{
+			strconv.Itoa(r.IterationCount),
+			r.Timestamp.Format(time.RFC3339),
+			strconv.Itoa(hop.Hop),
+			hop.IP,
+			hop.Name,
+			formatFloat(rtt),
+			formatFloat(hop.Loss),
+			strconv.Itoa(hop.ASN),
+			hop.Country,
+		}
+		if multiSample {
+			row = append(row, formatFloat(hop.RTTMin), formatFloat(hop.RTTMax))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// MarshalXML renders the result as a <traceroute> element with one <hop>
+// element per hop. Identifying fields are attributes, measurements are
+// elements and the raw command output is wrapped in a CDATA section. Lists
+// are wrapped in a parent element that is left out when they are empty.
+func (r TracerouteResult) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain TracerouteResult
+	type cdata struct {
+		Text string `xml:",cdata"`
+	}
+	out := struct {
+		plain
+		ElapsedTime   string                     `xml:"elapsedTime,attr,omitempty"`
+		Loops         *xmlList[LoopInfo]         `xml:"loops"`
+		Warnings      *xmlList[string]           `xml:"warnings"`
+		Alerts        *xmlList[AlertEntry]       `xml:"alerts"`
+		SLAViolations *xmlList[SLAViolation]     `xml:"slaViolations"`
+		ChangedHops   *xmlList[int]              `xml:"changedHops"`
+		RecentFlaps   *xmlList[FlapEvent]        `xml:"flaps"`
+		History       *xmlList[HistoryEntry]     `xml:"history"`
+		RTTAnomalies  *xmlList[RTTAnomaly]       `xml:"rttAnomalies"`
+		Paths         *xmlList[TracerouteResult] `xml:"paths"`
+		RawOutput     *cdata                     `xml:"rawOutput,omitempty"`
+	}{
+		plain:         plain(r),
+		Loops:         newXMLList("loop", r.LoopsDetected),
+		Warnings:      newXMLList("warning", r.Warnings),
+		Alerts:        newXMLList("alert", r.Alerts),
+		SLAViolations: newXMLList("violation", r.SLAViolations),
+		ChangedHops:   newXMLList("hop", r.ChangedHops),
+		RecentFlaps:   newXMLList("flap", r.RecentFlaps),
+		History:       newXMLList("iteration", r.History),
+		RTTAnomalies:  newXMLList("anomaly", r.RTTAnomalies),
+		Paths:         newXMLList("traceroute", r.Paths),
+	}
+	if r.ElapsedTime != 0 {
+		out.ElapsedTime = r.ElapsedTime.String()
+	}
+	if r.RawOutput != nil {
+		out.RawOutput = &cdata{Text: *r.RawOutput}
+	}
+
+	start.Name = xml.Name{Local: "traceroute"}
+	return e.EncodeElement(out, start)
+}
+
+// MarshalXML renders the hop as a <hop> element, the MPLS label stack is
+// wrapped in an <mpls> element when there is one
+func (h HopResult) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain HopResult
+	out := struct {
+		plain
+		MPLSLabels *xmlList[MPLSLabel] `xml:"mpls"`
+	}{
+		plain:      plain(h),
+		MPLSLabels: newXMLList("label", h.MPLSLabels),
+	}
+	return e.EncodeElement(out, start)
+}
+
+// xmlList renders a list as a parent element holding one element per item.
+// encoding/xml writes the parent of an "a>b" field even when the list is
+// empty, a nil *xmlList is left out instead.
+type xmlList[T any] struct {
+	item  string
+	items []T
+}
+
+// newXMLList returns the list of items named item, nil when it is empty
+func newXMLList[T any](item string, items []T) *xmlList[T] {
+	if len(items) == 0 {
+		return nil
+	}
+	return &xmlList[T]{item: item, items: items}
+}
+
+func (l *xmlList[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, item := range l.items {
+		if err := e.EncodeElement(item, xml.StartElement{Name: xml.Name{Local: l.item}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// ExportTable writes a fixed-width table meant for reading in a terminal,
+// followed by a summary line. Hops that lost probes are prefixed with "!".
+func (r TracerouteResult) ExportTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOP\tIP\tHOSTNAME\tRTT (ms)\tLOSS %\tASN\tCOUNTRY")
+
+	for _, hop := range r.Hops {
+		marker := " "
+		if hop.Loss > 0 {
+			marker = "!"
+		}
+		rtt, asn := "-", "-"
+		if len(hop.RTTSamples) > 0 {
+			rtt = fmt.Sprintf("%.3f", hop.RTTAvg)
+		}
+		if hop.ASN != 0 {
+			asn = strconv.Itoa(hop.ASN)
+		}
+		country := hop.Country
+		if country == "" {
+			country = "-"
+		}
+		fmt.Fprintf(tw, "%s%d\t%s\t%s\t%s\t%.1f\t%s\t%s\n",
+			marker, hop.Hop, hop.IP, hop.Name, rtt, hop.Loss, asn, country)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	reached := "no"
+	if r.DestinationReached {
+		reached = "yes"
+	}
+	_, err := fmt.Fprintf(w, "\n%d hops, destination reached: %s, elapsed: %s\n",
+		len(r.Hops), reached, r.ElapsedTime.Round(time.Millisecond))
+	return err
+}
+
+// writeResult writes the result in one of the command line output formats,
+// JSON being the default
+func writeResult(w io.Writer, r TracerouteResult, output string, pretty bool) error {
+	switch output {
+	case "table":
+		return r.ExportTable(w)
+	case "csv":
+		return r.ExportCSV(w)
+	case "ndjson":
+		return newNDJSONWriter(w).Write(r)
+	case "dot":
+		return ExportDOT(w, r)
+	case "mermaid":
+		_, err := io.WriteString(w, mermaidFormatter(r.Hops))
+		return err
+	case "influx":
+		_, err := io.WriteString(w, r.ExportInfluxDB(""))
+		return err
+	case "proto":
+		msg, err := r.MarshalProto()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(msg)
+		return err
+	case "msgpack":
+		msg, err := r.MarshalMsgPack()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(msg)
+		return err
+	case "proto-json":
+		return writeProtoJSONResult(w, resultToProto(r), pretty)
+	case "xml":
+		resultXML, err := xml.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, xml.Header+string(resultXML))
+		return err
+	default:
+		return r.ExportJSON(w, pretty)
+	}
+}
+
+// writeProtoJSONResult writes a message in the proto3 JSON mapping followed
+// by a newline. protojson varies its whitespace between builds, so the
+// output is compacted, or indented with two spaces when pretty is set.
+func writeProtoJSONResult(w io.Writer, msg proto.Message, pretty bool) error {
+	out, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if pretty {
+		err = json.Indent(&buf, out, "", "  ")
+	} else {
+		err = json.Compact(&buf, out)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, buf.String())
+	return err
+}
+
+// formatFloat renders a float rounded to microsecond precision without
+// trailing zeros
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1000)/1000, 'f', -1, 64)
+}
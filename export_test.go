@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testResult returns a result with every list and optional field set
+func testResult() TracerouteResult {
+	timestamp := time.Date(2024, 5, 1, 12, 30, 15, 250000000, time.UTC)
+	rawOutput := "traceroute to example.com (93.184.216.34), 30 hops max\n"
+	return TracerouteResult{
+		Host:          "example.com",
+		ExecutionID:   "6f1c2a4e-8b3d-4c5e-9f60-7a8b9c0d1e2f",
+		PluginVersion: pluginVersion,
+		SchemaVersion: schemaVersion,
+		Hops: []HopResult{
+			{
+				Hop: 1, IP: "192.168.1.1", Name: "gateway.lan", RTT: 1.5,
+				RTTSamples: []float64{1.2, 1.5, 1.8}, RTTMin: 1.2, RTTMax: 1.8, RTTAvg: 1.5,
+				RTTStdDev: 0.245, Jitter: 0.3, ProbesSent: 3, ProbeProtocol: "icmp", Status: HopStatusOK,
+			},
+			{
+				Hop: 2, IP: "*", Name: "*", RTTSamples: []float64{}, ProbesSent: 3, Loss: 100,
+				ProbeProtocol: "icmp", Status: HopStatusNoResponse,
+			},
+			{
+				Hop: 3, IP: "10.0.0.1", Name: "10.0.0.1", RTT: 12.25, RTTSamples: []float64{12.25},
+				RTTMin: 12.25, RTTMax: 12.25, RTTAvg: 12.25, ProbesSent: 3, Loss: 66.67,
+				ProbeProtocol: "icmp", Status: HopStatusPartial, ASN: 64500, ASNOrg: "Example Transit",
+				MPLSLabels: []MPLSLabel{{Label: 24001, Exp: 0, Stack: true, TTL: 1}},
+			},
+			{
+				Hop: 4, IP: "10.0.0.1", Name: "10.0.0.1", RTT: 13, RTTSamples: []float64{13},
+				RTTMin: 13, RTTMax: 13, RTTAvg: 13, ProbesSent: 1, ProbeProtocol: "icmp", Status: HopStatusLoop,
+			},
+		},
+		AddressFamily:      "ipv4",
+		SourceAddress:      "192.168.1.20",
+		PacketSize:         60,
+		Timestamp:          timestamp,
+		HasMPLS:            true,
+		HasLoop:            true,
+		LoopsDetected:      []LoopInfo{{StartHop: 3, EndHop: 4, IP: "10.0.0.1"}},
+		RawOutput:          &rawOutput,
+		Warnings:           []string{"hop 2 did not answer"},
+		HasAlerts:          true,
+		Alerts:             []AlertEntry{{Hop: 3, Type: "loss", Threshold: 50, Actual: 66.67}},
+		SLABreached:        true,
+		SLAViolations:      []SLAViolation{{Constraint: "maxRTT", Limit: 10, Actual: 13, Excess: 3}},
+		CommandDurationMs:  3012.5,
+		DNSDurationMs:      4.25,
+		IterationCount:     2,
+		ElapsedTime:        90 * time.Second,
+		PathChanged:        true,
+		ChangedHops:        []int{3},
+		FlapCount:          1,
+		RecentFlaps:        []FlapEvent{{Hop: 3, PreviousIP: "10.0.0.2", CurrentIP: "10.0.0.1", Timestamp: timestamp}},
+		History:            []HistoryEntry{{Iteration: 1, Timestamp: timestamp.Add(-time.Minute), Host: "example.com", AddressFamily: "ipv4", HopCount: 4, LastHop: "10.0.0.2"}},
+		RTTAnomalies:       []RTTAnomaly{{Hop: 3, CurrentRTT: 12.25, Mean: 5, StdDev: 1.5, Sigmas: 4.83}},
+		Fingerprint:        "c0ffee",
+		DestinationReached: false,
+		Paths: []TracerouteResult{{
+			Host:      "example.com",
+			Hops:      []HopResult{{Hop: 1, IP: "192.168.1.1", Name: "gateway.lan", RTT: 1.5, RTTSamples: []float64{1.5}, ProbesSent: 1}},
+			Timestamp: timestamp,
+		}},
+	}
+}
+
+func TestMarshalXMLGolden(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	tests := []struct {
+		name   string
+		result TracerouteResult
+	}{
+		{"full", testResult()},
+		{"empty_lists", TracerouteResult{
+			Host:          "192.0.2.10",
+			PluginVersion: pluginVersion,
+			SchemaVersion: schemaVersion,
+			Hops: []HopResult{
+				{Hop: 1, IP: "*", Name: "*", RTTSamples: []float64{}, ProbesSent: 3, Loss: 100, ProbeProtocol: "udp", Status: HopStatusNoResponse},
+				{Hop: 2, IP: "192.0.2.10", Name: "192.0.2.10", RTT: 8, RTTSamples: []float64{8}, RTTMin: 8, RTTMax: 8, RTTAvg: 8, ProbesSent: 3, ProbeProtocol: "udp"},
+			},
+			AddressFamily:      "ipv4",
+			PacketSize:         60,
+			Timestamp:          timestamp,
+			DestinationReached: true,
+			ReachedAtHop:       2,
+			LoopsDetected:      []LoopInfo{},
+			Alerts:             []AlertEntry{},
+			ChangedHops:        []int{},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := xml.MarshalIndent(tt.result, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "result_"+tt.name+".xml")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("XML does not match %s, run go test -update to rewrite it\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
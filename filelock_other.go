@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd)
+
+package main
+
+import "os"
+
+// lockFile is a no-op on platforms without flock, appends from a single
+// process are still serialized by historyFileMu
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
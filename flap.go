@@ -0,0 +1,65 @@
+package main
+
+import "time"
+
+// maxFlapHistory is how many flap events are kept
+const maxFlapHistory = 100
+
+// FlapEvent records a hop answered by a different address than in the
+// previous iteration, as happens when ECMP spreads probes over paths
+type FlapEvent struct {
+	Hop        int       `json:"hop" xml:"hop,attr"`
+	PreviousIP string    `json:"previousIP" xml:"previousIP,attr"`
+	CurrentIP  string    `json:"currentIP" xml:"currentIP,attr"`
+	Timestamp  time.Time `json:"timestamp" xml:"timestamp,attr"`
+}
+
+// recordFlaps appends a flap event for every hop answered by a different
+// address in current than in previous. Hops that did not answer in either
+// are not flaps.
+func (p *TraceroutePlugin) recordFlaps(previous, current TracerouteResult) {
+	before := respondingHops(previous)
+	after := respondingHops(current)
+	for _, number := range sortedHopNumbers(after) {
+		old, ok := before[number]
+		if !ok || old.IP == after[number].IP {
+			continue
+		}
+		p.FlapHistory = append(p.FlapHistory, FlapEvent{
+			Hop:        number,
+			PreviousIP: old.IP,
+			CurrentIP:  after[number].IP,
+			Timestamp:  current.Timestamp,
+		})
+	}
+	if len(p.FlapHistory) > maxFlapHistory {
+		p.FlapHistory = append([]FlapEvent{}, p.FlapHistory[len(p.FlapHistory)-maxFlapHistory:]...)
+	}
+}
+
+// recentFlaps returns the flap events within the stored iterations of the
+// hops that flapped more than threshold times there
+func (p *TraceroutePlugin) recentFlaps(threshold int) []FlapEvent {
+	var since time.Time
+	if len(p.Results) > 0 {
+		since = p.Results[0].Timestamp
+	}
+
+	counts := map[int]int{}
+	var window []FlapEvent
+	for _, event := range p.FlapHistory {
+		if event.Timestamp.Before(since) {
+			continue
+		}
+		counts[event.Hop]++
+		window = append(window, event)
+	}
+
+	flaps := []FlapEvent{}
+	for _, event := range window {
+		if counts[event.Hop] > threshold {
+			flaps = append(flaps, event)
+		}
+	}
+	return flaps
+}
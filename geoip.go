@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// geoIPReader looks up hops in a MaxMind DB (.mmdb) file such as
+// GeoLite2-City
+type geoIPReader struct {
+	path string
+	db   *maxminddb.Reader
+}
+
+// geoIPRecord is the part of a GeoLite2-City record attached to hops
+type geoIPRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// geoLocation is the geographic information attached to a hop
+type geoLocation struct {
+	country   string
+	city      string
+	latitude  float64
+	longitude float64
+}
+
+// geoIPCache keeps the most recently opened database across calls
+type geoIPCache struct {
+	mu     sync.Mutex
+	reader *geoIPReader
+}
+
+// open returns a reader for the database at path, reusing the previous
+// reader when the path has not changed
+func (c *geoIPCache) open(path string) (*geoIPReader, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.reader != nil && c.reader.path == path {
+		return c.reader, nil
+	}
+	reader, err := openGeoIP(path)
+	if err != nil {
+		return nil, err
+	}
+	c.reader = reader
+	return reader, nil
+}
+
+// openGeoIP loads a MaxMind DB file into memory. The file is read rather
+// than mapped so a replaced reader never needs closing while a lookup may
+// still use it.
+func openGeoIP(path string) (*geoIPReader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %v", err)
+	}
+	db, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoIP database %s: %v", path, err)
+	}
+	return &geoIPReader{path: path, db: db}, nil
+}
+
+// lookup returns the location of an address, or ok=false when the database
+// has no entry for it
+func (r *geoIPReader) lookup(ip net.IP) (geoLocation, bool, error) {
+	if ip.To4() == nil && r.db.Metadata.IPVersion == 4 {
+		return geoLocation{}, false, nil
+	}
+	var record geoIPRecord
+	_, ok, err := r.db.LookupNetwork(ip, &record)
+	if err != nil || !ok {
+		return geoLocation{}, false, err
+	}
+	return geoLocation{
+		country:   record.Country.ISOCode,
+		city:      record.City.Names["en"],
+		latitude:  record.Location.Latitude,
+		longitude: record.Location.Longitude,
+	}, true, nil
+}
+
+// lookupGeoIP attaches geographic data to each public hop address
+func (p *TraceroutePlugin) lookupGeoIP(path string, hops []HopResult) error {
+	reader, err := p.geoIP.open(path)
+	if err != nil {
+		return err
+	}
+
+	for i := range hops {
+		ip := hops[i].ipAddr()
+		if ip == nil || !isGeoRoutable(ip) {
+			continue
+		}
+		loc, ok, err := reader.lookup(ip)
+		if err != nil || !ok {
+			continue
+		}
+		hops[i].Country = loc.country
+		hops[i].City = loc.city
+		hops[i].Latitude = loc.latitude
+		hops[i].Longitude = loc.longitude
+	}
+	return nil
+}
+
+// isGeoRoutable reports whether an address can have a meaningful location,
+// private and reserved ranges never do
+func isGeoRoutable(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// geoIPTestDB is a GeoLite2-City style database written with
+// github.com/maxmind/mmdbwriter. It holds 81.2.69.0/24 (London, GB),
+// 89.160.20.112/28 (Linköping, SE), 2a02:cf40::/29 (Berlin, DE) and
+// 67.43.156.0/24 with only a country (BT).
+var geoIPTestDB = filepath.Join("testdata", "GeoLite2-City-Test.mmdb")
+
+func TestGeoIPLookup(t *testing.T) {
+	reader, err := openGeoIP(geoIPTestDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip   string
+		want geoLocation
+		ok   bool
+	}{
+		{"81.2.69.160", geoLocation{country: "GB", city: "London", latitude: 51.5142, longitude: -0.0931}, true},
+		{"89.160.20.120", geoLocation{country: "SE", city: "Linköping", latitude: 58.4167, longitude: 15.6167}, true},
+		{"2a02:cf40::1", geoLocation{country: "DE", city: "Berlin", latitude: 52.52, longitude: 13.405}, true},
+		{"67.43.156.1", geoLocation{country: "BT"}, true},
+		{"8.8.8.8", geoLocation{}, false},
+		{"2001:4860::8888", geoLocation{}, false},
+	}
+	for _, tt := range tests {
+		got, ok, err := reader.lookup(net.ParseIP(tt.ip))
+		if err != nil {
+			t.Errorf("%s: %v", tt.ip, err)
+			continue
+		}
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: got %+v, %v; want %+v, %v", tt.ip, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLookupGeoIP(t *testing.T) {
+	hops := hopsAt("192.168.1.1", "*", "81.2.69.160", "8.8.8.8", "2a02:cf40::1")
+	p := NewPlugin()
+	if err := p.lookupGeoIP(geoIPTestDB, hops); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ country, city string }{{"", ""}, {"", ""}, {"GB", "London"}, {"", ""}, {"DE", "Berlin"}}
+	for i, hop := range hops {
+		if hop.Country != want[i].country || hop.City != want[i].city {
+			t.Errorf("hop %d: got %q %q, want %q %q", hop.Hop, hop.Country, hop.City, want[i].country, want[i].city)
+		}
+	}
+
+	if err := p.lookupGeoIP(filepath.Join(t.TempDir(), "missing.mmdb"), hops); err == nil {
+		t.Error("expected an error for a missing database")
+	}
+}
+
+func TestOpenGeoIPInvalid(t *testing.T) {
+	valid, err := os.ReadFile(geoIPTestDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"empty":     {},
+		"garbage":   []byte("not a MaxMind database"),
+		"truncated": valid[:len(valid)/2],
+	} {
+		path := filepath.Join(t.TempDir(), name+".mmdb")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openGeoIP(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestGeoIPCorrupt overwrites each byte of the test database in turn and
+// checks that opening and looking up addresses fails cleanly
+func TestGeoIPCorrupt(t *testing.T) {
+	valid, err := os.ReadFile(geoIPTestDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips := []string{"81.2.69.160", "89.160.20.120", "2a02:cf40::1", "67.43.156.1", "8.8.8.8"}
+	path := filepath.Join(t.TempDir(), "corrupt.mmdb")
+	for i := range valid {
+		data := append([]byte(nil), valid...)
+		data[i] ^= 0xff
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		reader, err := openGeoIP(path)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			reader.lookup(net.ParseIP(ip))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	traceroutepb "github.com/NetScout-Go/Plugin_traceroute/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcShutdownTimeout bounds how long calls in progress may take to finish
+// once the server is asked to stop
+const grpcShutdownTimeout = 5 * time.Second
+
+// grpcServer implements TracerouteService of proto/traceroute.proto.
+// Requests are served over HTTP/2 without TLS.
+type grpcServer struct {
+	traceroutepb.UnimplementedTracerouteServiceServer
+
+	plugin *TraceroutePlugin
+
+	// pluginMu is held exclusively by iteration mode requests, which
+	// change the plugin's history, see executeShared
+	pluginMu sync.RWMutex
+}
+
+// runGRPC serves TracerouteService on addr until SIGINT or SIGTERM is
+// received
+func runGRPC(plugin *TraceroutePlugin, addr string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to serve gRPC: %v", err)
+	}
+	server := newGRPCServer(plugin)
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(grpcShutdownTimeout):
+			server.Stop()
+		}
+	}()
+
+	if err := server.Serve(ln); err != nil {
+		return fmt.Errorf("failed to serve gRPC: %v", err)
+	}
+	return nil
+}
+
+// newGRPCServer returns a gRPC server with TracerouteService registered
+func newGRPCServer(plugin *TraceroutePlugin) *grpc.Server {
+	server := grpc.NewServer()
+	traceroutepb.RegisterTracerouteServiceServer(server, &grpcServer{plugin: plugin})
+	return server
+}
+
+// RunTrace runs a single trace and returns the complete result
+func (s *grpcServer) RunTrace(ctx context.Context, req *traceroutepb.TracerouteRequest) (*traceroutepb.TracerouteResponse, error) {
+	result, err := executeShared(ctx, s.plugin, requestParams(req), &s.pluginMu)
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+	traceResult, ok := result.(TracerouteResult)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "the request did not produce a trace result but %T", result)
+	}
+	return tracerouteResponse(traceResult), nil
+}
+
+// WatchTrace runs a single trace and streams each hop as soon as it is known
+func (s *grpcServer) WatchTrace(req *traceroutepb.TracerouteRequest, stream grpc.ServerStreamingServer[traceroutepb.HopEvent]) error {
+	params := requestParams(req)
+	host, _ := params["host"].(string)
+
+	// The paths of discoverAllPaths are traced concurrently, a stream
+	// takes one message at a time
+	var sendMu sync.Mutex
+	var sendErr error
+	ctx := withHopObserver(stream.Context(), func(hop HopResult) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if sendErr == nil {
+			sendErr = stream.Send(&traceroutepb.HopEvent{Host: host, Hop: hopToProto(hop)})
+		}
+	})
+	if _, err := executeShared(ctx, s.plugin, params, &s.pluginMu); err != nil {
+		return grpcStatus(err)
+	}
+	return sendErr
+}
+
+// grpcStatus converts an Execute error into the status of the call
+func grpcStatus(err error) error {
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.As(err, new(*ValidationError)):
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
+
+// requestParams converts a TracerouteRequest message into Execute
+// parameters, leaving out fields that were not set
+func requestParams(req *traceroutepb.TracerouteRequest) map[string]interface{} {
+	params := map[string]interface{}{}
+	if req.Host != "" {
+		params["host"] = req.Host
+	}
+	if req.MaxHops != 0 {
+		params["maxHops"] = float64(req.MaxHops)
+	}
+	if req.ProbeCount != 0 {
+		params["probeCount"] = float64(req.ProbeCount)
+	}
+	if req.Protocol != "" {
+		params["protocol"] = req.Protocol
+	}
+	if req.Port != 0 {
+		params["port"] = float64(req.Port)
+	}
+	if req.UseNative {
+		params["useNative"] = true
+	}
+	if req.ResolveDns != nil {
+		params["resolveDNS"] = *req.ResolveDns
+	}
+	if req.OverallTimeout != 0 {
+		params["overallTimeout"] = req.OverallTimeout
+	}
+	if req.FirstHop != 0 {
+		params["firstHop"] = float64(req.FirstHop)
+	}
+	return params
+}
+
+// hopToProto converts a hop to a Hop message
+func hopToProto(hop HopResult) *traceroutepb.Hop {
+	return &traceroutepb.Hop{
+		Number:      int32(hop.Hop),
+		Ip:          hop.IP,
+		Name:        hop.Name,
+		RttMs:       hop.RTTAvg,
+		LossPercent: hop.Loss,
+		Status:      hop.Status.String(),
+		Asn:         int32(hop.ASN),
+		Country:     hop.Country,
+		RttSamples:  hop.RTTSamples,
+	}
+}
+
+// tracerouteResponse converts a result to a TracerouteResponse message
+func tracerouteResponse(r TracerouteResult) *traceroutepb.TracerouteResponse {
+	resp := &traceroutepb.TracerouteResponse{
+		Host:               r.Host,
+		AddressFamily:      r.AddressFamily,
+		DestinationReached: r.DestinationReached,
+		ReachedAtHop:       int32(r.ReachedAtHop),
+		TimestampUnix:      r.Timestamp.Unix(),
+	}
+	for _, hop := range r.Hops {
+		resp.Hops = append(resp.Hops, hopToProto(hop))
+	}
+	return resp
+}
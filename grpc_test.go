@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	traceroutepb "github.com/NetScout-Go/Plugin_traceroute/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcTestClient serves TracerouteService on a local port and returns a
+// grpc-go client connected to it
+func grpcTestClient(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newGRPCServer(NewPlugin())
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///"+ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// newProtoMessage returns an empty message of proto/traceroute.proto with
+// the given fields set
+func newProtoMessage(t *testing.T, file protoreflect.FileDescriptor, name string, fields map[string]interface{}) *dynamicpb.Message {
+	t.Helper()
+	msg := dynamicpb.NewMessage(file.Messages().ByName(protoreflect.Name(name)))
+	for field, value := range fields {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(field))
+		if fd == nil {
+			t.Fatalf("%s has no field %s", name, field)
+		}
+		msg.Set(fd, protoreflect.ValueOf(value))
+	}
+	return msg
+}
+
+// protoGet returns the value of a field of msg
+func protoGet(msg protoreflect.Message, field string) protoreflect.Value {
+	return msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(field)))
+}
+
+func TestGRPCRunTrace(t *testing.T) {
+	fakeTraceroute(t)
+	file := tracerouteProto(t)
+	conn := grpcTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := newProtoMessage(t, file, "TracerouteRequest", map[string]interface{}{
+		"host":        "192.0.2.10",
+		"max_hops":    int32(5),
+		"resolve_dns": false,
+	})
+	resp := dynamicpb.NewMessage(file.Messages().ByName("TracerouteResponse"))
+	if err := conn.Invoke(ctx, traceroutepb.TracerouteService_RunTrace_FullMethodName, req, resp); err != nil {
+		t.Fatal(err)
+	}
+	checkNoUnknownFields(t, resp, "TracerouteResponse")
+
+	if got := protoGet(resp, "host").String(); got != "192.0.2.10" {
+		t.Errorf("got host %q", got)
+	}
+	if !protoGet(resp, "destination_reached").Bool() || protoGet(resp, "reached_at_hop").Int() != 3 {
+		t.Errorf("got destination_reached %v at hop %d, want true at hop 3",
+			protoGet(resp, "destination_reached").Bool(), protoGet(resp, "reached_at_hop").Int())
+	}
+	if protoGet(resp, "timestamp_unix").Int() <= 0 {
+		t.Error("timestamp_unix is not set")
+	}
+
+	hops := protoGet(resp, "hops").List()
+	want := []struct {
+		ip      string
+		rtt     float64
+		status  string
+		samples []float64
+	}{
+		{"192.0.2.1", 0.4, "OK", []float64{0.5, 0.4, 0.3}},
+		{"*", 0, "NO RESPONSE", nil},
+		{"192.0.2.10", 8, "OK", []float64{8, 7, 9}},
+	}
+	if hops.Len() != len(want) {
+		t.Fatalf("got %d hops, want %d", hops.Len(), len(want))
+	}
+	for i, w := range want {
+		hop := hops.Get(i).Message()
+		var samples []float64
+		for j := 0; j < protoGet(hop, "rtt_samples").List().Len(); j++ {
+			samples = append(samples, protoGet(hop, "rtt_samples").List().Get(j).Float())
+		}
+		if protoGet(hop, "number").Int() != int64(i+1) || protoGet(hop, "ip").String() != w.ip ||
+			protoGet(hop, "status").String() != w.status || !reflect.DeepEqual(samples, w.samples) {
+			t.Errorf("hop %d: got %v", i+1, hop)
+		}
+		if rtt := protoGet(hop, "rtt_ms").Float(); rtt-w.rtt > 1e-9 || w.rtt-rtt > 1e-9 {
+			t.Errorf("hop %d: got rtt %v, want %v", i+1, rtt, w.rtt)
+		}
+	}
+}
+
+func TestGRPCWatchTrace(t *testing.T) {
+	fakeTraceroute(t)
+	file := tracerouteProto(t)
+	conn := grpcTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, traceroutepb.TracerouteService_WatchTrace_FullMethodName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := newProtoMessage(t, file, "TracerouteRequest", map[string]interface{}{"host": "192.0.2.10", "resolve_dns": false})
+	if err := stream.SendMsg(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	var ips []string
+	for {
+		event := dynamicpb.NewMessage(file.Messages().ByName("HopEvent"))
+		err := stream.RecvMsg(event)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkNoUnknownFields(t, event, "HopEvent")
+		if got := protoGet(event, "host").String(); got != "192.0.2.10" {
+			t.Errorf("got host %q", got)
+		}
+		hop := protoGet(event, "hop").Message()
+		if got := protoGet(hop, "number").Int(); got != int64(len(ips)+1) {
+			t.Errorf("got hop %d, want %d", got, len(ips)+1)
+		}
+		ips = append(ips, protoGet(hop, "ip").String())
+	}
+	if want := []string{"192.0.2.1", "*", "192.0.2.10"}; !reflect.DeepEqual(ips, want) {
+		t.Errorf("got hops %v, want %v", ips, want)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	file := tracerouteProto(t)
+	conn := grpcTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := newProtoMessage(t, file, "TracerouteRequest", map[string]interface{}{"max_hops": int32(5)})
+	resp := dynamicpb.NewMessage(file.Messages().ByName("TracerouteResponse"))
+	err := conn.Invoke(ctx, traceroutepb.TracerouteService_RunTrace_FullMethodName, req, resp)
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Errorf("without a host got %v, want InvalidArgument", err)
+	}
+
+	err = conn.Invoke(ctx, "/netscout.traceroute.v1.TracerouteService/Traceroute", req, resp)
+	if got := status.Code(err); got != codes.Unimplemented {
+		t.Errorf("for an unknown method got %v, want Unimplemented", err)
+	}
+}
+
+func TestRequestParams(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *traceroutepb.TracerouteRequest
+		want map[string]interface{}
+	}{
+		{
+			name: "all fields",
+			req: &traceroutepb.TracerouteRequest{
+				Host: "example.com", MaxHops: 20, ProbeCount: 2, Protocol: "tcp",
+				Port: 443, UseNative: true, ResolveDns: proto.Bool(false), OverallTimeout: 2.5, FirstHop: 3,
+			},
+			want: map[string]interface{}{
+				"host": "example.com", "maxHops": 20.0, "probeCount": 2.0, "protocol": "tcp",
+				"port": 443.0, "useNative": true, "resolveDNS": false, "overallTimeout": 2.5, "firstHop": 3.0,
+			},
+		},
+		{
+			// Unset fields are left to the plugin defaults
+			name: "host only",
+			req:  &traceroutepb.TracerouteRequest{Host: "example.com"},
+			want: map[string]interface{}{"host": "example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestParams(tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// historyFileMu serializes appends from plugins in this process, the file
+// lock guards against other processes sharing the file
+var historyFileMu sync.Mutex
+
+// appendHistory writes one iteration result as a JSON line to the history
+// file, creating it if needed
+func appendHistory(path string, result TracerouteResult) error {
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	historyFileMu.Lock()
+	defer historyFileMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("failed to lock history file: %v", err)
+	}
+	defer unlockFile(f)
+
+	// Start on a fresh line after a write that was cut short
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+
+	// A single write keeps the line whole if the process dies mid-way
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to write history file: %v", err)
+	}
+	return f.Sync()
+}
+
+// loadHistory reads the results stored in a history file. Lines that do
+// not parse, such as one cut short by a crash during a write, are skipped.
+func loadHistory(path string) ([]TracerouteResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []TracerouteResult
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var result TracerouteResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			continue
+		}
+		results = append(results, result)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %v", err)
+	}
+	return results, nil
+}
+
+// restoreHistory replaces the iteration state with the results stored in
+// the history file. A missing file leaves the state empty.
+func (p *TraceroutePlugin) restoreHistory(path string) error {
+	p.historyPath = path
+	results, err := loadHistory(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	p.IterationCount = results[len(results)-1].IterationCount
+	if p.IterationCount < len(results) {
+		p.IterationCount = len(results)
+	}
+	for i := range results {
+		results[i].IterationCount = 0
+	}
+	p.Results = results
+	p.StartTime = results[0].Timestamp
+	p.replayResults()
+	p.trimHistory(p.Config.MaxHistory)
+	return nil
+}
+
+// MergeHistory adds the results of other to those of p, for example from
+// agents that each ran part of the iterations, and orders them by
+// timestamp. Results present in both are kept once and counted once in
+// the iteration count. The statistics derived from the results are
+// recomputed. Results of different hosts can not be merged. The merged
+// results are not written to the history file.
+func (p *TraceroutePlugin) MergeHistory(other *TraceroutePlugin) error {
+	host := ""
+	for _, results := range [][]TracerouteResult{p.Results, other.Results} {
+		for _, r := range results {
+			if host == "" {
+				host = r.Host
+			} else if r.Host != host {
+				return fmt.Errorf("cannot merge histories of different hosts: %s and %s", host, r.Host)
+			}
+		}
+	}
+	if other == p {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for _, r := range p.Results {
+		seen[r.ExecutionID] = true
+	}
+	merged := append([]TracerouteResult{}, p.Results...)
+	duplicates := 0
+	for _, r := range other.Results {
+		if r.ExecutionID != "" && seen[r.ExecutionID] {
+			duplicates++
+			continue
+		}
+		merged = append(merged, cloneResult(r))
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+
+	p.Results = merged
+	p.IterationCount += other.IterationCount - duplicates
+	if p.IterationCount < len(merged) {
+		p.IterationCount = len(merged)
+	}
+	if other.StartTime.Before(p.StartTime) {
+		p.StartTime = other.StartTime
+	}
+	p.replayResults()
+	p.trimHistory(p.Config.MaxHistory)
+	return nil
+}
+
+// replayResults rebuilds the rolling, alert, SLA, availability and flap
+// state from the stored results, oldest first
+func (p *TraceroutePlugin) replayResults() {
+	p.rolling = nil
+	p.alertStreaks = nil
+	p.slaBreachCount, p.slaBreachPeakPercent = 0, 0
+	p.availability = nil
+	p.FlapHistory = nil
+	for i, r := range p.Results {
+		p.recordRolling(r, p.Config.RollingWindow)
+		p.updateAlertStreaks(r.Alerts)
+		p.recordSLA(r.SLAViolations)
+		p.recordAvailability(r)
+		if i > 0 {
+			p.recordFlaps(p.Results[i-1], r)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultRequestTimeout bounds a single API request when the caller does
+// not pass requestTimeout
+const defaultRequestTimeout = 60 * time.Second
+
+// httpAPI serves traces as JSON over HTTP
+type httpAPI struct {
+	plugin *TraceroutePlugin
+
+	// pluginMu is held exclusively by iteration mode requests, which
+	// change the plugin's history, see executeShared
+	pluginMu sync.RWMutex
+}
+
+// runHTTP serves the JSON API on addr until SIGINT or SIGTERM is received
+func runHTTP(plugin *TraceroutePlugin, addr string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	api := &httpAPI{plugin: plugin}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/trace", api.handleTrace)
+	mux.HandleFunc("/trace/stream", api.handleTraceStream)
+	mux.HandleFunc("/health", api.handleHealth)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve HTTP API: %v", err)
+	}
+	return nil
+}
+
+// handleHealth reports that the server is up
+func (a *httpAPI) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleTrace runs a trace with parameters from a JSON body (POST) or the
+// query string (GET)
+func (a *httpAPI) handleTrace(w http.ResponseWriter, r *http.Request) {
+	var params map[string]interface{}
+	switch r.Method {
+	case http.MethodGet:
+		params = queryParams(r.URL.Query())
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&params); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+			return
+		}
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	timeout, err := a.checkRequest(params)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	result, err := executeShared(ctx, a.plugin, params, &a.pluginMu)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, result)
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSONError(w, http.StatusRequestTimeout, err.Error())
+	case errors.As(err, new(*ValidationError)):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// handleTraceStream runs a native trace and sends each hop as a
+// Server-Sent Event as soon as it is probed, followed by a "done" event
+// carrying the complete result
+func (a *httpAPI) handleTraceStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	params := queryParams(r.URL.Query())
+	// Only the native implementation reports hops while tracing, and a
+	// stream carries a single trace
+	params["useNative"] = true
+	params["continueToIterate"] = false
+
+	// Report invalid parameters before the event stream starts
+	timeout, err := a.checkRequest(params)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// The request context is cancelled when the client disconnects
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx = withHopObserver(ctx, func(hop HopResult) {
+		writeSSE(w, "", hop)
+		flusher.Flush()
+	})
+	result, err := executeShared(ctx, a.plugin, params, &a.pluginMu)
+	if r.Context().Err() != nil {
+		return // Client went away
+	}
+	if err != nil {
+		writeSSE(w, "error", map[string]string{"error": err.Error()})
+	} else {
+		writeSSE(w, "done", result)
+	}
+	flusher.Flush()
+}
+
+// checkRequest validates the parameters of a trace request as Execute will
+// and returns the timeout of the request
+func (a *httpAPI) checkRequest(params map[string]interface{}) (time.Duration, error) {
+	if _, err := a.plugin.prepareParams(params); err != nil {
+		return 0, err
+	}
+	in := paramReader{params: params}
+	timeout := defaultRequestTimeout
+	if requestTimeout, ok := in.float("requestTimeout"); ok {
+		if requestTimeout <= 0 {
+			return 0, paramError("requestTimeout", "must be positive")
+		}
+		timeout = time.Duration(requestTimeout * float64(time.Second))
+	}
+	return timeout, in.err
+}
+
+// writeSSE writes one Server-Sent Event with a JSON data payload. An empty
+// event name sends the default "message" event.
+func writeSSE(w http.ResponseWriter, event string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// queryParams converts query string values to Execute parameters. Values
+// are kept as strings, numbers and booleans are converted where the
+// parameters are read.
+func queryParams(values url.Values) map[string]interface{} {
+	params := make(map[string]interface{}, len(values))
+	for key := range values {
+		params[key] = values.Get(key)
+	}
+	return params
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// influxWriteTimeout bounds each write request to InfluxDB
+const influxWriteTimeout = 5 * time.Second
+
+// influxWriter pushes results to the InfluxDB v2 HTTP write API
+type influxWriter struct {
+	url    string
+	token  string
+	org    string
+	bucket string
+	client *http.Client
+}
+
+// newInfluxWriter creates a writer for the server at baseURL, e.g.
+// "http://localhost:8086"
+func newInfluxWriter(baseURL, token, org, bucket string) *influxWriter {
+	return &influxWriter{
+		url:    strings.TrimSuffix(baseURL, "/") + "/api/v2/write",
+		token:  token,
+		org:    org,
+		bucket: bucket,
+		client: &http.Client{Timeout: influxWriteTimeout},
+	}
+}
+
+// influxWriterFromParams returns a writer when every InfluxDB parameter is
+// given and nil otherwise
+func influxWriterFromParams(params map[string]interface{}) *influxWriter {
+	baseURL, _ := params["influxdbURL"].(string)
+	token, _ := params["influxdbToken"].(string)
+	org, _ := params["influxdbOrg"].(string)
+	bucket, _ := params["influxdbBucket"].(string)
+	if baseURL == "" || token == "" || org == "" || bucket == "" {
+		return nil
+	}
+	return newInfluxWriter(baseURL, token, org, bucket)
+}
+
+// write posts the per-hop metrics of r, retrying once when the server
+// answers with a 5xx status or can not be reached
+func (w *influxWriter) write(r TracerouteResult) error {
+	body := r.ExportInfluxDB("")
+	if body == "" {
+		return nil
+	}
+
+	err := w.post(body)
+	if _, transient := err.(*influxTransientError); transient {
+		err = w.post(body)
+	}
+	return err
+}
+
+// influxTransientError is a failed write that is worth retrying
+type influxTransientError struct {
+	err error
+}
+
+func (e *influxTransientError) Error() string {
+	return e.err.Error()
+}
+
+// post sends line protocol to the write endpoint
+func (w *influxWriter) post(body string) error {
+	query := url.Values{"org": {w.org}, "bucket": {w.bucket}, "precision": {"ns"}}
+	req, err := http.NewRequest(http.MethodPost, w.url+"?"+query.Encode(), strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %v", err)
+	}
+	req.Header.Set("Authorization", "Token "+w.token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return &influxTransientError{fmt.Errorf("failed to write to InfluxDB: %v", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("failed to write to InfluxDB: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	if resp.StatusCode >= 500 {
+		return &influxTransientError{err}
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// interfaceInfo describes a local interface for --list-interfaces
+type interfaceInfo struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses"`
+	Flags     []string `json:"flags"`
+	// Default is set on the interface holding the default route
+	Default bool `json:"default,omitempty"`
+}
+
+// listInterfaces returns the interfaces that are up and not loopback, the
+// ones usable as sourceInterface
+func listInterfaces() ([]interfaceInfo, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	defaultName := defaultInterface()
+
+	infos := []interfaceInfo{}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		info := interfaceInfo{
+			Name:      iface.Name,
+			Addresses: []string{},
+			Flags:     strings.Split(iface.Flags.String(), "|"),
+			Default:   iface.Name == defaultName,
+		}
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				info.Addresses = append(info.Addresses, addr.String())
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// defaultInterface returns the name of the interface holding the default
+// route, or "" when it cannot be determined. The routing table is read from
+// /proc/net/route on Linux. Elsewhere the interface is the one owning the
+// local address the system picks for a public destination, which sends no
+// packets since the socket is UDP and never written to.
+func defaultInterface() string {
+	if name, ok := defaultRouteInterface("/proc/net/route"); ok {
+		return name
+	}
+
+	conn, err := net.Dial("udp", "192.0.2.1:33434")
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return ""
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(local.IP) {
+				return iface.Name
+			}
+		}
+	}
+	return ""
+}
+
+// defaultRouteInterface reads a Linux routing table in the format of
+// /proc/net/route and returns the interface of the default route with the
+// lowest metric. ok is false when the table cannot be read.
+func defaultRouteInterface(path string) (name string, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	bestMetric := -1
+	scanner := bufio.NewScanner(f)
+	// Skip the header line
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		metric, err := strconv.Atoi(fields[6])
+		if err != nil {
+			continue
+		}
+		if bestMetric < 0 || metric < bestMetric {
+			name, bestMetric = fields[0], metric
+		}
+	}
+	return name, true
+}
+
+// selectInterfaceByCIDR returns the first interface that is up and has an
+// address in the subnet cidr, see interfaceAutoSelect
+func selectInterfaceByCIDR(cidr string) (net.Interface, error) {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return net.Interface{}, paramError("interfaceAutoSelect", fmt.Sprintf("must be a CIDR prefix such as 10.10.0.0/24, got %q", cidr))
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return net.Interface{}, fmt.Errorf("failed to list interfaces: %v", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if addressInSubnet(iface, subnet) != nil {
+			return iface, nil
+		}
+	}
+	return net.Interface{}, paramError("interfaceAutoSelect", fmt.Sprintf("no interface has an address in %s", subnet))
+}
+
+// addressInSubnet returns the first address of iface in subnet, or nil when
+// it has none
+func addressInSubnet(iface net.Interface, subnet *net.IPNet) net.IP {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && subnet.Contains(ipNet.IP) {
+			return ipNet.IP
+		}
+	}
+	return nil
+}
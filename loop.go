@@ -0,0 +1,63 @@
+package main
+
+// loopWindow is how many hops ahead an address may reappear before the
+// repetition is treated as a routing loop
+const loopWindow = 3
+
+// LoopInfo describes an address that answered more than one hop within a
+// short span, which indicates a routing loop
+type LoopInfo struct {
+	StartHop int    `json:"startHop" xml:"startHop,attr"`
+	EndHop   int    `json:"endHop" xml:"endHop,attr"`
+	IP       string `json:"ip" xml:"ip,attr"`
+}
+
+// detectLoops returns every address that repeats within loopWindow hops.
+// Hops that did not answer are ignored.
+func detectLoops(hops []HopResult) []LoopInfo {
+	loops := []LoopInfo{}
+	for i, hop := range hops {
+		if hop.IP == "*" {
+			continue
+		}
+		for j := i + 1; j < len(hops) && hops[j].Hop-hop.Hop <= loopWindow; j++ {
+			if hops[j].IP == hop.IP {
+				loops = append(loops, LoopInfo{StartHop: hop.Hop, EndHop: hops[j].Hop, IP: hop.IP})
+				break
+			}
+		}
+	}
+	return loops
+}
+
+// markLoops sets the status of the hops that repeat the address of an
+// earlier hop to HopStatusLoop
+func markLoops(hops []HopResult, loops []LoopInfo) {
+	for _, loop := range loops {
+		for i := range hops {
+			if hops[i].Hop == loop.EndHop && hops[i].IP == loop.IP {
+				hops[i].Status = HopStatusLoop
+			}
+		}
+	}
+}
+
+// truncateAtLoop drops the hops after the end of the first loop, since a
+// looping path never reaches the destination
+func truncateAtLoop(hops []HopResult, loops []LoopInfo) []HopResult {
+	if len(loops) == 0 {
+		return hops
+	}
+	end := loops[0].EndHop
+	for _, loop := range loops[1:] {
+		if loop.EndHop < end {
+			end = loop.EndHop
+		}
+	}
+	for i, hop := range hops {
+		if hop.Hop > end {
+			return hops[:i]
+		}
+	}
+	return hops
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// hopsAt returns answered hops numbered from 1 with the given addresses,
+// "*" for a hop that did not answer
+func hopsAt(ips ...string) []HopResult {
+	hops := make([]HopResult, len(ips))
+	for i, ip := range ips {
+		hops[i] = HopResult{Hop: i + 1, IP: ip, Status: HopStatusOK}
+		if ip == "*" {
+			hops[i].Status = HopStatusNoResponse
+		}
+	}
+	return hops
+}
+
+func TestDetectLoops(t *testing.T) {
+	tests := []struct {
+		name string
+		hops []HopResult
+		want []LoopInfo
+	}{
+		{
+			name: "no loop",
+			hops: hopsAt("10.0.0.1", "10.0.1.1", "10.0.2.1", "93.184.216.34"),
+			want: []LoopInfo{},
+		},
+		{
+			name: "loop between two routers",
+			hops: hopsAt("10.0.0.1", "10.0.1.1", "10.0.2.1", "10.0.1.1", "10.0.2.1", "10.0.1.1"),
+			want: []LoopInfo{
+				{StartHop: 2, EndHop: 4, IP: "10.0.1.1"},
+				{StartHop: 3, EndHop: 5, IP: "10.0.2.1"},
+				{StartHop: 4, EndHop: 6, IP: "10.0.1.1"},
+			},
+		},
+		{
+			name: "repeat beyond the window",
+			hops: hopsAt("10.0.0.1", "10.0.1.1", "10.0.2.1", "10.0.3.1", "10.0.0.1"),
+			want: []LoopInfo{},
+		},
+		{
+			name: "repeat within the window",
+			hops: hopsAt("10.0.0.1", "10.0.1.1", "10.0.2.1", "10.0.0.1"),
+			want: []LoopInfo{{StartHop: 1, EndHop: 4, IP: "10.0.0.1"}},
+		},
+		{
+			name: "unanswered hops are not a loop",
+			hops: hopsAt("10.0.0.1", "*", "*", "*", "93.184.216.34"),
+			want: []LoopInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLoops(tt.hops); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectLoops() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateAtLoop(t *testing.T) {
+	hops := hopsAt("10.0.0.1", "10.0.1.1", "10.0.2.1", "10.0.1.1", "10.0.2.1", "10.0.1.1", "10.0.2.1")
+	loops := detectLoops(hops)
+
+	got := truncateAtLoop(hops, loops)
+	if len(got) != 4 || got[len(got)-1].Hop != 4 {
+		t.Fatalf("got %d hops ending at hop %d, want the 4 hops up to the end of the first loop", len(got), got[len(got)-1].Hop)
+	}
+	if again := detectLoops(got); !reflect.DeepEqual(again, []LoopInfo{{StartHop: 2, EndHop: 4, IP: "10.0.1.1"}}) {
+		t.Errorf("loops after truncating = %v, want only the first loop", again)
+	}
+
+	// The loop that closes first decides, not the one listed first
+	loops = []LoopInfo{{StartHop: 1, EndHop: 6, IP: "a"}, {StartHop: 2, EndHop: 5, IP: "b"}}
+	if got := truncateAtLoop(hops, loops); len(got) != 5 {
+		t.Errorf("got %d hops, want 5", len(got))
+	}
+
+	if got := truncateAtLoop(hops, nil); len(got) != len(hops) {
+		t.Errorf("got %d hops without loops, want all %d", len(got), len(hops))
+	}
+}
+
+func TestMarkLoops(t *testing.T) {
+	hops := hopsAt("10.0.0.1", "10.0.1.1", "*", "10.0.1.1", "93.184.216.34")
+	markLoops(hops, detectLoops(hops))
+
+	want := []HopStatus{HopStatusOK, HopStatusOK, HopStatusNoResponse, HopStatusLoop, HopStatusOK}
+	for i, hop := range hops {
+		if hop.Status != want[i] {
+			t.Errorf("hop %d: got status %v, want %v", hop.Hop, hop.Status, want[i])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mermaidFormatter returns a Mermaid flowchart of the hops, top to bottom.
+// Each node shows the hop number, address, hostname and RTT, hops that did
+// not answer are drawn dashed.
+func mermaidFormatter(hops []HopResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "graph TD\n")
+
+	var timeouts []string
+	for i, hop := range hops {
+		id := fmt.Sprintf("HOP%d", hop.Hop)
+		label := fmt.Sprintf("%d: %s", hop.Hop, hop.IP)
+		if hop.IP == "*" {
+			timeouts = append(timeouts, id)
+		} else {
+			if hop.Name != "" && hop.Name != hop.IP {
+				label += `\n(` + hop.Name + ")"
+			}
+			label += `\nRTT: ` + formatFloat(hop.RTTAvg) + "ms"
+		}
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", id, mermaidEscaper.Replace(label))
+		if i > 0 {
+			fmt.Fprintf(&b, "    HOP%d --> %s\n", hops[i-1].Hop, id)
+		}
+	}
+
+	if len(timeouts) > 0 {
+		fmt.Fprintf(&b, "    classDef timeout stroke-dasharray: 5 5\n")
+		fmt.Fprintf(&b, "    class %s timeout\n", strings.Join(timeouts, ","))
+	}
+	return b.String()
+}
+
+// mermaidEscaper replaces the characters that end a quoted Mermaid label
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;")
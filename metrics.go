@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultServeInterval is the pause between traces in --serve mode when no
+// --interval is given
+const defaultServeInterval = 60 * time.Second
+
+// metricsCollector exposes the latest trace result on /metrics
+type metricsCollector struct {
+	mu      sync.Mutex
+	handler http.Handler
+
+	iterations     prometheus.Counter
+	hopRTT         *prometheus.GaugeVec
+	hopLoss        *prometheus.GaugeVec
+	hopCount       *prometheus.GaugeVec
+	destinationHit *prometheus.GaugeVec
+}
+
+// newMetricsCollector registers the metrics of traces to host. The gauges
+// have no value until the first trace completes.
+func newMetricsCollector(host string) *metricsCollector {
+	labels := prometheus.Labels{"host": host}
+	m := &metricsCollector{
+		iterations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "traceroute_iterations_total",
+			Help:        "Number of completed traces.",
+			ConstLabels: labels,
+		}),
+		hopRTT: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "traceroute_hop_rtt_milliseconds",
+			Help:        "Average round trip time of each responding hop.",
+			ConstLabels: labels,
+		}, []string{"hop", "hop_ip"}),
+		hopLoss: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "traceroute_hop_loss_percent",
+			Help:        "Packet loss of each hop.",
+			ConstLabels: labels,
+		}, []string{"hop", "hop_ip"}),
+		hopCount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "traceroute_hop_count",
+			Help:        "Number of hops in the latest trace.",
+			ConstLabels: labels,
+		}, nil),
+		destinationHit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "traceroute_destination_reached",
+			Help:        "Whether the latest trace reached the destination.",
+			ConstLabels: labels,
+		}, nil),
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(m.iterations, m.hopRTT, m.hopLoss, m.hopCount, m.destinationHit)
+	m.handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return m
+}
+
+// record replaces the hop metrics with those of a completed trace
+func (m *metricsCollector) record(result TracerouteResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.iterations.Inc()
+	// Hops of earlier traces may be gone or answered by other routers
+	m.hopRTT.Reset()
+	m.hopLoss.Reset()
+	for _, hop := range result.Hops {
+		labels := prometheus.Labels{"hop": strconv.Itoa(hop.Hop), "hop_ip": hop.IP}
+		if len(hop.RTTSamples) > 0 {
+			m.hopRTT.With(labels).Set(hop.RTTAvg)
+		}
+		m.hopLoss.With(labels).Set(hop.Loss)
+	}
+	m.hopCount.WithLabelValues().Set(float64(len(result.Hops)))
+
+	reached := 0.0
+	if result.DestinationReached {
+		reached = 1
+	}
+	m.destinationHit.WithLabelValues().Set(reached)
+}
+
+// ServeHTTP writes the metrics in the Prometheus exposition format
+func (m *metricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
+
+// runServe traces in the background every interval and serves the latest
+// result as Prometheus metrics on addr until SIGINT or SIGTERM is received
+func runServe(plugin *TraceroutePlugin, params map[string]interface{}, addr string, interval time.Duration) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	host, _ := params["host"].(string)
+	collector := newMetricsCollector(host)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		params["continueToIterate"] = true
+		for ctx.Err() == nil {
+			result, err := plugin.Execute(ctx, params)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "traceroute failed: %v\n", err)
+				}
+			} else if traceResult, ok := result.(TracerouteResult); ok {
+				collector.record(traceResult)
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve metrics: %v", err)
+	}
+	return nil
+}
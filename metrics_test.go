@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// scrapeMetrics fetches url and returns the value of every series, keyed
+// by the metric name and its sorted labels
+func scrapeMetrics(t *testing.T, url string) (map[string]float64, map[string]dto.MetricType) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	types := map[string]dto.MetricType{}
+	for name, family := range families {
+		types[name] = family.GetType()
+		for _, metric := range family.GetMetric() {
+			labels := make([]string, 0, len(metric.GetLabel()))
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+			}
+			slices.Sort(labels)
+			key := name + "{" + strings.Join(labels, ",") + "}"
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				values[key] = metric.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				values[key] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return values, types
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	collector := newMetricsCollector("example.com")
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	got, _ := scrapeMetrics(t, server.URL+"/metrics")
+	want := map[string]float64{`traceroute_iterations_total{host="example.com"}`: 0}
+	if !maps.Equal(got, want) {
+		t.Errorf("before the first trace got %v, want %v", got, want)
+	}
+
+	collector.record(testResult())
+	got, types := scrapeMetrics(t, server.URL+"/metrics")
+	want = map[string]float64{
+		`traceroute_iterations_total{host="example.com"}`:                                  1,
+		`traceroute_hop_rtt_milliseconds{hop="1",hop_ip="192.168.1.1",host="example.com"}`: 1.5,
+		`traceroute_hop_rtt_milliseconds{hop="3",hop_ip="10.0.0.1",host="example.com"}`:    12.25,
+		`traceroute_hop_rtt_milliseconds{hop="4",hop_ip="10.0.0.1",host="example.com"}`:    13,
+		`traceroute_hop_loss_percent{hop="1",hop_ip="192.168.1.1",host="example.com"}`:     0,
+		`traceroute_hop_loss_percent{hop="2",hop_ip="*",host="example.com"}`:               100,
+		`traceroute_hop_loss_percent{hop="3",hop_ip="10.0.0.1",host="example.com"}`:        66.67,
+		`traceroute_hop_loss_percent{hop="4",hop_ip="10.0.0.1",host="example.com"}`:        0,
+		`traceroute_hop_count{host="example.com"}`:                                         4,
+		`traceroute_destination_reached{host="example.com"}`:                               0,
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	wantTypes := map[string]dto.MetricType{
+		"traceroute_iterations_total":     dto.MetricType_COUNTER,
+		"traceroute_hop_rtt_milliseconds": dto.MetricType_GAUGE,
+		"traceroute_hop_loss_percent":     dto.MetricType_GAUGE,
+		"traceroute_hop_count":            dto.MetricType_GAUGE,
+		"traceroute_destination_reached":  dto.MetricType_GAUGE,
+	}
+	if !maps.Equal(types, wantTypes) {
+		t.Errorf("got types %v, want %v", types, wantTypes)
+	}
+
+	// Hops of the previous trace are dropped
+	collector.record(TracerouteResult{
+		Host:               "example.com",
+		Hops:               []HopResult{{Hop: 1, IP: "93.184.216.34", RTTSamples: []float64{9}, RTTAvg: 9, ProbesSent: 3}},
+		DestinationReached: true,
+	})
+	got, _ = scrapeMetrics(t, server.URL+"/metrics")
+	want = map[string]float64{
+		`traceroute_iterations_total{host="example.com"}`:                                    2,
+		`traceroute_hop_rtt_milliseconds{hop="1",hop_ip="93.184.216.34",host="example.com"}`: 9,
+		`traceroute_hop_loss_percent{hop="1",hop_ip="93.184.216.34",host="example.com"}`:     0,
+		`traceroute_hop_count{host="example.com"}`:                                           1,
+		`traceroute_destination_reached{host="example.com"}`:                                 1,
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("after the second trace got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "golang.org/x/net/icmp"
+
+// MPLSLabel is one entry of the MPLS label stack a router received the probe
+// with, as reported in its ICMP extensions
+type MPLSLabel struct {
+	Label uint32 `json:"label" xml:"label"`
+	Exp   uint8  `json:"exp" xml:"exp"`
+	Stack bool   `json:"stack" xml:"stack"`
+	TTL   uint8  `json:"ttl" xml:"ttl"`
+}
+
+// parseMPLSLabels returns the MPLS label stack from the RFC 4950 extension
+// objects of an ICMP time exceeded or destination unreachable message, or
+// nil if it carries none
+func parseMPLSLabels(msg *icmp.Message) []MPLSLabel {
+	var extensions []icmp.Extension
+	switch body := msg.Body.(type) {
+	case *icmp.TimeExceeded:
+		extensions = body.Extensions
+	case *icmp.DstUnreach:
+		extensions = body.Extensions
+	}
+
+	var labels []MPLSLabel
+	for _, ext := range extensions {
+		stack, ok := ext.(*icmp.MPLSLabelStack)
+		if !ok {
+			continue
+		}
+		for _, entry := range stack.Labels {
+			labels = append(labels, MPLSLabel{
+				Label: uint32(entry.Label),
+				Exp:   uint8(entry.TC),
+				Stack: entry.S,
+				TTL:   uint8(entry.TTL),
+			})
+		}
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MarshalMsgPack encodes the result as MessagePack. The document is the one
+// written by ExportJSON, with the same keys and values, so consumers can
+// switch formats without changing how they read the result. Whole numbers
+// are encoded as integers in their smallest format and map keys are sorted.
+func (r TracerouteResult) MarshalMsgPack() ([]byte, error) {
+	resultJSON, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(resultJSON))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	doc, err = msgPackNumbers(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// msgPackNumbers replaces the numbers of a value decoded from JSON with
+// UseNumber by integers where they are whole, floats otherwise
+func msgPackNumbers(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", v)
+		}
+		return f, nil
+	case []interface{}:
+		for i, elem := range v {
+			elem, err := msgPackNumbers(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = elem
+		}
+	case map[string]interface{}:
+		for key, elem := range v {
+			elem, err := msgPackNumbers(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = elem
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMarshalMsgPack(t *testing.T) {
+	result := testResult()
+	data, err := result.MarshalMsgPack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.UseLooseInterfaceDecoding(true)
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]interface{}{
+		"host":               "example.com",
+		"executionID":        "6f1c2a4e-8b3d-4c5e-9f60-7a8b9c0d1e2f",
+		"timestamp":          "2024-05-01T12:30:15.25Z",
+		"elapsedTime":        "1m30s",
+		"iterationCount":     int64(2),
+		"commandDurationMs":  3012.5,
+		"destinationReached": false,
+		"changedHops":        []interface{}{int64(3)},
+	} {
+		if got := doc[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %#v, want %#v", key, got, want)
+		}
+	}
+	hop := doc["hops"].([]interface{})[0].(map[string]interface{})
+	for key, want := range map[string]interface{}{
+		"hop":        int64(1),
+		"host":       "192.168.1.1",
+		"rtt":        1.5,
+		"rttSamples": []interface{}{1.2, 1.5, 1.8},
+		"status":     "OK",
+	} {
+		if got := hop[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("hop %s: got %#v, want %#v", key, got, want)
+		}
+	}
+
+	// The document is the one written by ExportJSON
+	resultJSON, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseJSON(bytes.NewReader(resultJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := withIPAddrs(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded MessagePack differs from the result:\n got %+v\nwant %+v", got, want)
+	}
+}
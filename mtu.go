@@ -0,0 +1,73 @@
+package main
+
+import "context"
+
+// defaultMTUCeiling is the largest packet size tried by mtuDiscovery when no
+// packetSize is given
+const defaultMTUCeiling = 1500
+
+// discoverMTU binary searches, hop by hop, for the largest packet that
+// reaches each hop with the don't fragment bit set and records it in the
+// hop's MTU. A hop can not carry more than the hops before it, so each
+// search starts from the previous hop's MTU, and hops that did not answer
+// keep it. floor is assumed to fit everywhere. The returned path MTU is
+// that of the last hop.
+func (p *TraceroutePlugin) discoverMTU(ctx context.Context, opts traceOptions, useNative bool, hops []HopResult, floor, ceiling int) (int, error) {
+	ctx, span := startSpan(ctx, "traceroute.mtu_discovery")
+	// The search probes are not hops of the trace
+	ctx = withHopObserver(ctx, func(HopResult) {})
+
+	mtu := ceiling
+	for i := range hops {
+		hop := hops[i]
+		if hop.IP == "*" {
+			hops[i].MTU = mtu
+			continue
+		}
+
+		// fits reports whether a packet of size bytes reaches the hop, rather
+		// than being dropped or answered by an earlier router
+		fits := func(size int) (bool, error) {
+			probeOpts := opts
+			probeOpts.firstHop, probeOpts.maxHops = hop.Hop, hop.Hop
+			probeOpts.packetSize = size
+			probeOpts.dontFragment = true
+			result, err := p.runProbes(ctx, probeOpts, useNative)
+			if err != nil {
+				return false, err
+			}
+			for _, probed := range result.Hops {
+				if probed.Hop == hop.Hop && probed.IP == hop.IP {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+
+		ok, err := fits(mtu)
+		if err != nil {
+			endSpan(span, err)
+			return 0, err
+		}
+		if !ok {
+			low, high := floor, mtu
+			for high-low > 1 {
+				mid := (low + high) / 2
+				ok, err := fits(mid)
+				if err != nil {
+					endSpan(span, err)
+					return 0, err
+				}
+				if ok {
+					low = mid
+				} else {
+					high = mid
+				}
+			}
+			mtu = low
+		}
+		hops[i].MTU = mtu
+	}
+	span.End()
+	return mtu, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultPathCount is the number of flows traced by discoverAllPaths when
+// pathCount is not given
+const defaultPathCount = 4
+
+// pathFingerprint identifies a path by its sequence of hop addresses
+func pathFingerprint(result TracerouteResult) string {
+	ips := make([]string, 0, len(result.Hops))
+	for _, hop := range result.Hops {
+		ips = append(ips, hop.IP)
+	}
+	return strings.Join(ips, ",")
+}
+
+// discoverPaths traces the target over several Paris traceroute flows at
+// once, one flow identifier per trace, so that load balancers spread them
+// over their equal-cost paths. The result is the trace of the first flow
+// with every distinct path attached in Paths.
+func (p *TraceroutePlugin) discoverPaths(ctx context.Context, params map[string]interface{}) (TracerouteResult, error) {
+	in := paramReader{params: params}
+	pathCount, ok := in.int("pathCount")
+	if !ok {
+		pathCount = defaultPathCount
+	}
+	baseFlowID, _ := in.int("flowID")
+	if in.err != nil {
+		return TracerouteResult{}, in.err
+	}
+	if pathCount < 1 {
+		return TracerouteResult{}, paramError("pathCount", fmt.Sprintf("must be at least 1, got %d", pathCount))
+	}
+
+	type flowResult struct {
+		flowID int
+		result TracerouteResult
+		err    error
+	}
+	results := make(chan flowResult, pathCount)
+	var wg sync.WaitGroup
+	for i := 0; i < pathCount; i++ {
+		flowID := baseFlowID + i
+		flowParams := make(map[string]interface{}, len(params)+1)
+		for k, v := range params {
+			flowParams[k] = v
+		}
+		flowParams["discoverAllPaths"] = false
+		flowParams["parisTraceroute"] = true
+		flowParams["flowID"] = float64(flowID)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := p.performTraceroute(ctx, flowParams)
+			results <- flowResult{flowID: flowID, result: result, err: err}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var flows []flowResult
+	for flow := range results {
+		if flow.err != nil {
+			return TracerouteResult{}, flow.err
+		}
+		flows = append(flows, flow)
+	}
+	sort.Slice(flows, func(i, j int) bool { return flows[i].flowID < flows[j].flowID })
+
+	// Keep the lowest flow identifier that took each path
+	seen := map[string]bool{}
+	var paths []TracerouteResult
+	for _, flow := range flows {
+		fingerprint := pathFingerprint(flow.result)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		flow.result.Fingerprint = fingerprint
+		paths = append(paths, flow.result)
+	}
+
+	result := flows[0].result
+	result.Fingerprint = pathFingerprint(result)
+	result.Paths = paths
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// requestMaxLineSize bounds a single request line of --multiplex and
+// --socket
+const requestMaxLineSize = 1 << 20
+
+// runMultiplex answers newline-delimited JSON requests read from in until
+// it is closed, writing one NDJSON response per request to out. Requests
+// are the parameters of --execute and run one after another on plugin, so
+// iterations share its history. A request {"method": "reset"} clears the
+// iteration state instead of tracing.
+func runMultiplex(plugin *TraceroutePlugin, in io.Reader, out io.Writer) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var pluginMu sync.RWMutex
+	records := newNDJSONWriter(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), requestMaxLineSize)
+	for scanner.Scan() && ctx.Err() == nil {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := records.Write(executeRequestLine(ctx, plugin, line, &pluginMu)); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %v", err)
+	}
+	return nil
+}
+
+// executeRequestLine runs the request of one line and returns the response,
+// the result or an error report. The optional "method" is "execute", the
+// default, or "reset".
+func executeRequestLine(ctx context.Context, plugin *TraceroutePlugin, line []byte, pluginMu *sync.RWMutex) interface{} {
+	var params map[string]interface{}
+	if err := json.Unmarshal(line, &params); err != nil {
+		return map[string]string{"error": fmt.Sprintf("invalid JSON request: %v", err)}
+	}
+	if params == nil {
+		return map[string]string{"error": "invalid JSON request: expected a JSON object"}
+	}
+
+	method, _ := params["method"].(string)
+	delete(params, "method")
+	switch method {
+	case "", "execute":
+	case "reset":
+		pluginMu.Lock()
+		defer pluginMu.Unlock()
+		plugin.Reset()
+		return map[string]string{"status": "reset"}
+	default:
+		return map[string]string{"error": fmt.Sprintf("unknown method %q, must be execute or reset", method)}
+	}
+
+	result, err := executeShared(ctx, plugin, params, pluginMu)
+	if err != nil {
+		return errorReport(err)
+	}
+	return result
+}
+
+// executeShared runs Execute on a plugin that serves concurrent requests.
+// Iteration requests change the plugin's history and hold pluginMu
+// exclusively. Single traces run side by side under the read lock, they
+// only write the DNS, ASN, GeoIP, result and negative DNS caches, which
+// are internally synchronized.
+func executeShared(ctx context.Context, plugin *TraceroutePlugin, params map[string]interface{}, pluginMu *sync.RWMutex) (interface{}, error) {
+	in := paramReader{params: plugin.withParamDefaults(params)}
+	if continueToIterate, _ := in.bool("continueToIterate"); continueToIterate {
+		pluginMu.Lock()
+		defer pluginMu.Unlock()
+	} else {
+		pluginMu.RLock()
+		defer pluginMu.RUnlock()
+	}
+	return plugin.Execute(ctx, params)
+}
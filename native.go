@@ -0,0 +1,633 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	// icmpCodeFragmentationNeeded is the destination unreachable code of a
+	// probe that needed fragmenting but had the don't fragment bit set
+	icmpCodeFragmentationNeeded = 4
+
+	ipProtocolICMP   = 1
+	ipProtocolTCP    = 6
+	ipProtocolUDP    = 17
+	ipProtocolICMPv6 = 58
+
+	// nativeProbeTimeout is how long to wait for a reply to a single probe
+	nativeProbeTimeout = 3 * time.Second
+
+	// nativePollInterval bounds each ICMP read so cancellation and TCP
+	// connects can be polled
+	nativePollInterval = 50 * time.Millisecond
+
+	// retryBaseDelay is the pause before the first retry of a lost probe,
+	// it doubles with every further retry
+	retryBaseDelay = 100 * time.Millisecond
+
+	// maxRetryTimeouts bounds retryTimeouts
+	maxRetryTimeouts = 10
+)
+
+// listenPacket opens the raw ICMP socket of native traces, it is a
+// variable so the socket can be replaced in tests
+var listenPacket = icmp.ListenPacket
+
+// icmpSocket is the raw socket native traces send ICMP probes on and read
+// the replies to every probe from. p4 or p6 sets the IP options of probes
+// depending on the address family.
+type icmpSocket struct {
+	net.PacketConn
+	p4 *ipv4.PacketConn
+	p6 *ipv6.PacketConn
+}
+
+// listenICMP opens the ICMP socket of a native trace. icmp.ListenPacket
+// gives no access to the socket, so when the probes may not be fragmented
+// the socket is opened with that option set and wrapped the same way.
+func listenICMP(network, address string, v6, dontFragment bool) (icmpSocket, error) {
+	if !dontFragment {
+		conn, err := listenPacket(network, address)
+		if err != nil {
+			return icmpSocket{}, err
+		}
+		return icmpSocket{PacketConn: conn, p4: conn.IPv4PacketConn(), p6: conn.IPv6PacketConn()}, nil
+	}
+
+	lc := net.ListenConfig{
+		Control: func(network, address string, raw syscall.RawConn) error {
+			return setDontFragment(raw, v6)
+		},
+	}
+	conn, err := lc.ListenPacket(context.Background(), network, address)
+	if err != nil {
+		return icmpSocket{}, err
+	}
+	if v6 {
+		return icmpSocket{PacketConn: conn, p6: ipv6.NewPacketConn(conn)}, nil
+	}
+	return icmpSocket{PacketConn: conn, p4: ipv4.NewPacketConn(conn)}, nil
+}
+
+// setTTL sets the IPv4 time-to-live or IPv6 hop limit of subsequent probes
+func (s icmpSocket) setTTL(ttl int) error {
+	if s.p6 != nil {
+		return s.p6.SetHopLimit(ttl)
+	}
+	return s.p4.SetTTL(ttl)
+}
+
+// setTOS sets the IPv4 type-of-service or IPv6 traffic class byte of
+// subsequent probes
+func (s icmpSocket) setTOS(tos int) error {
+	if s.p6 != nil {
+		return s.p6.SetTrafficClass(tos)
+	}
+	return s.p4.SetTOS(tos)
+}
+
+// nativeTracer holds the state shared by all probes of a native trace
+type nativeTracer struct {
+	traceOptions
+	icmpConn icmpSocket
+	dst      *net.IPAddr
+	ipv6     bool
+	id       int
+}
+
+// performTracerouteNative runs a traceroute over raw sockets without relying
+// on the system traceroute binary
+func (p *TraceroutePlugin) performTracerouteNative(ctx context.Context, opts traceOptions) (TracerouteResult, error) {
+	dst := &net.IPAddr{IP: opts.target}
+	v6 := opts.target.To4() == nil
+
+	// Replies for every probe protocol arrive as ICMP messages
+	network, laddr := "ip4:icmp", "0.0.0.0"
+	if v6 {
+		network, laddr = "ip6:ipv6-icmp", "::"
+	}
+	if opts.source != nil {
+		laddr = opts.source.String()
+	}
+	// UDP and TCP probes set their options on sockets of their own
+	conn, err := listenICMP(network, laddr, v6, opts.dontFragment && opts.protocol == "icmp")
+	if errors.Is(err, os.ErrPermission) {
+		return TracerouteResult{}, permissionError(err)
+	}
+	if err != nil {
+		return TracerouteResult{}, err
+	}
+	defer conn.Close()
+
+	if opts.tos != 0 && opts.protocol == "icmp" {
+		if err := conn.setTOS(opts.tos); err != nil {
+			return TracerouteResult{}, fmt.Errorf("failed to set TOS: %v", err)
+		}
+	}
+
+	tracer := &nativeTracer{
+		traceOptions: opts,
+		icmpConn:     conn,
+		dst:          dst,
+		ipv6:         v6,
+		id:           (os.Getpid() + opts.flowID) & 0xffff,
+	}
+
+	hops := []HopResult{}
+	var output strings.Builder
+	fmt.Fprintf(&output, "traceroute to %s (%s), %d hops max (native %s)\n", opts.host, dst.IP, opts.maxHops, strings.ToUpper(opts.protocol))
+
+	start := time.Now()
+	_, commandSpan := startSpan(ctx, "traceroute.command", attribute.Bool("traceroute.native", true))
+	defer commandSpan.End()
+	seq := 0
+	destinationReached := false
+	for ttl := opts.firstHop; ttl <= opts.maxHops; ttl++ {
+		hopIP := "*"
+		samples := []float64{}
+		var labels []MPLSLabel
+		reached := false
+		unreachable := false
+		fragmentation := probeReply{}
+		retries := 0
+		fmt.Fprintf(&output, "%2d ", ttl)
+
+		for i := 0; i < opts.probeCount; i++ {
+			if seq > 0 && opts.waitTime > 0 {
+				select {
+				case <-ctx.Done():
+					return TracerouteResult{}, contextError(ctx.Err())
+				case <-time.After(opts.waitTime):
+				}
+			}
+			seq++
+			reply, err := tracer.probe(ctx, ttl, seq)
+			if err != nil {
+				return TracerouteResult{}, err
+			}
+			// A lost probe is often transient, retry it with backoff
+			for retry := 0; reply.ip == "*" && retry < opts.retryTimeouts; retry++ {
+				select {
+				case <-ctx.Done():
+					return TracerouteResult{}, contextError(ctx.Err())
+				case <-time.After(retryBaseDelay << retry):
+				}
+				retries++
+				seq++
+				reply, err = tracer.probe(ctx, ttl, seq)
+				if err != nil {
+					return TracerouteResult{}, err
+				}
+			}
+			if reply.ip == "*" {
+				output.WriteString(" *")
+				continue
+			}
+
+			if hopIP == "*" {
+				hopIP = reply.ip
+				fmt.Fprintf(&output, " %s", reply.ip)
+			}
+			fmt.Fprintf(&output, "  %.3f ms", reply.rtt)
+			if reply.fragmentationNeeded {
+				fmt.Fprintf(&output, " !F-%d", reply.nextHopMTU)
+				fragmentation = reply
+			}
+			samples = append(samples, reply.rtt)
+			if labels == nil {
+				labels = reply.labels
+			}
+			reached = reached || reply.reached
+			unreachable = unreachable || reply.unreachable
+		}
+		output.WriteString("\n")
+
+		hop := newHop(ttl, hopIP, hopIP, samples, opts.probeCount, opts.protocol)
+		hop.MPLSLabels = labels
+		hop.Retries = retries
+		if fragmentation.fragmentationNeeded {
+			hop.Status = HopStatusFragmentationNeeded
+			hop.NextHopMTU = fragmentation.nextHopMTU
+		} else if unreachable {
+			hop.Status = HopStatusUnreachable
+		}
+		hops = append(hops, hop)
+		notifyHop(ctx, hop)
+
+		if reached {
+			destinationReached = true
+			break
+		}
+		if fragmentation.fragmentationNeeded || unreachable {
+			// Probes with a higher TTL are dropped by the same router
+			break
+		}
+		if !opts.continueOnLoop && len(detectLoops(hops)) > 0 {
+			// A looping path never converges, stop probing further hops
+			break
+		}
+	}
+
+	rawOutput := output.String()
+	result := TracerouteResult{
+		Host:               opts.host,
+		Hops:               hops,
+		Timestamp:          time.Now().Truncate(time.Second),
+		DestinationReached: destinationReached,
+		RawOutput:          &rawOutput,
+		CommandDurationMs:  elapsedMs(start),
+	}
+	result.ProbeSourcePort, result.ProbeDestPort = probePorts(opts, opts.protocol)
+	return result, nil
+}
+
+// probeReply is the outcome of a single probe
+type probeReply struct {
+	// ip is the responding address, "*" when the probe was lost
+	ip      string
+	rtt     float64
+	reached bool
+	// unreachable is set when a router other than the target reported the
+	// target unreachable
+	unreachable bool
+	// labels is the MPLS label stack the responding router reported
+	labels []MPLSLabel
+	// fragmentationNeeded is set when a router could not forward the probe
+	// without fragmenting it, nextHopMTU is the MTU it reported, if any
+	fragmentationNeeded bool
+	nextHopMTU          int
+}
+
+// probe sends a single probe with the given TTL and waits for the matching
+// ICMP reply
+func (t *nativeTracer) probe(ctx context.Context, ttl, seq int) (probeReply, error) {
+	start := time.Now()
+	deadline := start.Add(nativeProbeTimeout)
+
+	var localPort int
+	var connected <-chan error
+
+	switch t.protocol {
+	case "icmp":
+		if err := t.icmpConn.setTTL(ttl); err != nil {
+			return probeReply{}, fmt.Errorf("failed to set TTL: %v", err)
+		}
+		msg, err := marshalEchoRequest(t.echoID(seq), seq, t.payloadSize(), t.ipv6)
+		if err != nil {
+			return probeReply{}, err
+		}
+		if _, err := t.icmpConn.WriteTo(msg, t.dst); err != nil {
+			return t.sendFailed(err)
+		}
+	case "udp":
+		conn, err := t.dialer(ttl).DialContext(ctx, t.network("udp"), net.JoinHostPort(t.dst.IP.String(), fmt.Sprint(t.port)))
+		if err != nil {
+			return probeReply{}, fmt.Errorf("failed to open UDP socket: %v", err)
+		}
+		defer conn.Close()
+		local := conn.LocalAddr().(*net.UDPAddr)
+		localPort = local.Port
+		payload := probePayload(t.payloadSize())
+		if t.paris {
+			payload = parisUDPPayload(len(payload), local.IP, t.dst.IP, localPort, t.port, parisUDPChecksum(seq))
+		}
+		if _, err := conn.Write(payload); err != nil {
+			return t.sendFailed(err)
+		}
+	case "tcp":
+		// A SYN that reaches the destination completes or is refused, while
+		// one that expires in transit produces an ICMP time-exceeded message
+		// The attempt is abandoned with the probe so a fixed source port is
+		// free again for the next one
+		dialCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		result := make(chan error, 1)
+		connected = result
+		go func() {
+			conn, err := t.dialer(ttl).DialContext(dialCtx, t.network("tcp"), net.JoinHostPort(t.dst.IP.String(), fmt.Sprint(t.port)))
+			if err == nil {
+				conn.Close()
+			}
+			result <- err
+		}()
+	default:
+		return probeReply{}, fmt.Errorf("unsupported protocol %q", t.protocol)
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		select {
+		case <-ctx.Done():
+			return probeReply{}, contextError(ctx.Err())
+		default:
+		}
+
+		if connected != nil {
+			select {
+			case err := <-connected:
+				if err == nil || connectionRefused(err) {
+					return probeReply{ip: t.dst.IP.String(), rtt: elapsedMs(start), reached: true}, nil
+				}
+				connected = nil
+			default:
+			}
+		}
+
+		now := time.Now()
+		if !now.Before(deadline) {
+			// Routers commonly rate-limit ICMP, treat it as a lost probe
+			return probeReply{ip: "*"}, nil
+		}
+		readDeadline := deadline
+		if now.Add(nativePollInterval).Before(deadline) {
+			readDeadline = now.Add(nativePollInterval)
+		}
+		if err := t.icmpConn.SetReadDeadline(readDeadline); err != nil {
+			return probeReply{}, err
+		}
+
+		n, peer, err := t.icmpConn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return probeReply{}, fmt.Errorf("failed to read reply: %v", err)
+		}
+
+		msg, err := icmp.ParseMessage(t.icmpProtocol(), buf[:n])
+		if err != nil {
+			continue
+		}
+		msgType, matched := t.matchReply(msg, seq, localPort)
+		if !matched {
+			continue
+		}
+
+		peerIP := peer.String()
+		if ipAddr, ok := peer.(*net.IPAddr); ok {
+			peerIP = ipAddr.IP.String()
+		}
+		// Only the target itself ends the trace, an unreachable message from
+		// a router in front of it means the probes get no further
+		fromTarget := net.ParseIP(peerIP).Equal(t.dst.IP)
+		reply := probeReply{
+			ip:          peerIP,
+			rtt:         elapsedMs(start),
+			reached:     msgType != ipv4.ICMPTypeTimeExceeded && fromTarget,
+			unreachable: msgType == ipv4.ICMPTypeDestinationUnreachable && !fromTarget,
+			labels:      parseMPLSLabels(msg),
+		}
+		// The probe stopped at a router in front of a smaller link
+		if needed, mtu := fragmentationNeeded(msg, buf[:n]); needed {
+			reply.reached = false
+			reply.unreachable = false
+			reply.fragmentationNeeded = true
+			reply.nextHopMTU = mtu
+		}
+		return reply, nil
+	}
+}
+
+// sendFailed reports a probe that could not be sent. Probes that may not be
+// fragmented and exceed the known path MTU are lost rather than an error.
+func (t *nativeTracer) sendFailed(err error) (probeReply, error) {
+	if t.dontFragment && messageTooLong(err) {
+		return probeReply{ip: "*"}, nil
+	}
+	return probeReply{}, fmt.Errorf("failed to send probe: %v", err)
+}
+
+// network returns the address family specific network name for a protocol
+func (t *nativeTracer) network(protocol string) string {
+	if t.ipv6 {
+		return protocol + "6"
+	}
+	return protocol + "4"
+}
+
+// dialer returns a dialer whose sockets send packets with the given TTL and
+// the configured TOS and don't fragment bit from the configured source
+// address
+func (t *nativeTracer) dialer(ttl int) *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout: nativeProbeTimeout,
+		Control: func(network, address string, raw syscall.RawConn) error {
+			if err := setTTL(raw, ttl, t.ipv6); err != nil {
+				return err
+			}
+			if t.tos != 0 {
+				if err := setTOS(rawSocket{raw: raw}, t.tos, t.ipv6); err != nil {
+					return err
+				}
+			}
+			if t.protocol == "tcp" && t.sourcePort != 0 {
+				// The port may still be held by the previous connection
+				if err := setReuseAddr(raw); err != nil {
+					return err
+				}
+			}
+			if t.dontFragment {
+				return setDontFragment(raw, t.ipv6)
+			}
+			return nil
+		},
+	}
+	// Paris probes share one source port so the flow stays constant
+	port, _ := probePorts(t.traceOptions, t.protocol)
+	switch {
+	case t.source == nil && port == 0:
+	case t.protocol == "udp":
+		dialer.LocalAddr = &net.UDPAddr{IP: t.source, Port: port}
+	case t.protocol == "tcp":
+		dialer.LocalAddr = &net.TCPAddr{IP: t.source, Port: port}
+	}
+	return dialer
+}
+
+// icmpProtocol returns the IP protocol number of the replies
+func (t *nativeTracer) icmpProtocol() int {
+	if t.ipv6 {
+		return ipProtocolICMPv6
+	}
+	return ipProtocolICMP
+}
+
+// rawSocket lets ipv4.NewConn and ipv6.NewConn set the options of a socket
+// that is not connected yet, from the Control function of a dialer. They
+// only use the raw connection of a TCP or UDP socket, which they recognize
+// by its methods.
+type rawSocket struct {
+	net.Conn
+	raw syscall.RawConn
+}
+
+func (s rawSocket) SyscallConn() (syscall.RawConn, error) { return s.raw, nil }
+
+func (s rawSocket) SetLinger(int) error { return nil }
+
+// setTOS sets the IPv4 type-of-service or IPv6 traffic class byte of the
+// packets sent on a socket
+func setTOS(c net.Conn, tos int, v6 bool) error {
+	if v6 {
+		return ipv6.NewConn(c).SetTrafficClass(tos)
+	}
+	return ipv4.NewConn(c).SetTOS(tos)
+}
+
+// matchReply reports whether the reply belongs to the probe that was just
+// sent, normalizing the ICMPv6 message type to its ICMPv4 equivalent
+func (t *nativeTracer) matchReply(msg *icmp.Message, seq, localPort int) (ipv4.ICMPType, bool) {
+	var msgType ipv4.ICMPType
+	switch msg.Type {
+	case ipv4.ICMPTypeEchoReply, ipv6.ICMPTypeEchoReply:
+		msgType = ipv4.ICMPTypeEchoReply
+	case ipv4.ICMPTypeTimeExceeded, ipv6.ICMPTypeTimeExceeded:
+		msgType = ipv4.ICMPTypeTimeExceeded
+	case ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable, ipv6.ICMPTypePacketTooBig:
+		msgType = ipv4.ICMPTypeDestinationUnreachable
+	default:
+		return 0, false
+	}
+
+	var quoted []byte
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		return msgType, msgType == ipv4.ICMPTypeEchoReply && t.protocol == "icmp" && t.echoMatches(body, seq)
+	case *icmp.TimeExceeded:
+		quoted = body.Data
+	case *icmp.DstUnreach:
+		quoted = body.Data
+	case *icmp.PacketTooBig:
+		quoted = body.Data
+	default:
+		return 0, false
+	}
+
+	// The error quotes the original IP header followed by the first eight
+	// bytes of the packet that triggered it
+	innerProtocol, innerDst, original, ok := parseInnerPacket(quoted, t.ipv6)
+	if !ok || !innerDst.Equal(t.dst.IP) {
+		return 0, false
+	}
+	srcPort := int(binary.BigEndian.Uint16(original[0:]))
+	dstPort := int(binary.BigEndian.Uint16(original[2:]))
+
+	switch innerProtocol {
+	case ipProtocolICMP, ipProtocolICMPv6:
+		if t.protocol != "icmp" {
+			return 0, false
+		}
+		probe, err := icmp.ParseMessage(innerProtocol, original)
+		if err != nil || (probe.Type != ipv4.ICMPTypeEcho && probe.Type != ipv6.ICMPTypeEchoRequest) {
+			return 0, false
+		}
+		echo, ok := probe.Body.(*icmp.Echo)
+		return msgType, ok && t.echoMatches(echo, seq)
+	case ipProtocolUDP:
+		// Paris probes are not told apart by the quoted checksum since
+		// NATs rewrite it, probes are sent one at a time so the fixed
+		// ports are enough to match the reply
+		return msgType, t.protocol == "udp" && srcPort == localPort && dstPort == t.port
+	case ipProtocolTCP:
+		return msgType, t.protocol == "tcp" && dstPort == t.port
+	}
+	return 0, false
+}
+
+// echoMatches reports whether an echo message carries the identifier and
+// sequence number of the probe with the given sequence
+func (t *nativeTracer) echoMatches(echo *icmp.Echo, seq int) bool {
+	return echo.ID == t.echoID(seq)&0xffff && echo.Seq == seq&0xffff
+}
+
+// parseInnerPacket extracts the protocol, destination address and leading
+// transport bytes of the packet quoted inside an ICMP error message
+func parseInnerPacket(quoted []byte, v6 bool) (int, net.IP, []byte, bool) {
+	if v6 {
+		header, err := ipv6.ParseHeader(quoted)
+		if err != nil || len(quoted) < ipv6.HeaderLen+8 {
+			return 0, nil, nil, false
+		}
+		return header.NextHeader, header.Dst, quoted[ipv6.HeaderLen:], true
+	}
+
+	header, err := ipv4.ParseHeader(quoted)
+	if err != nil || header.Len < ipv4.HeaderLen || len(quoted) < header.Len+8 {
+		return 0, nil, nil, false
+	}
+	return header.Protocol, header.Dst, quoted[header.Len:], true
+}
+
+// payloadSize returns the number of payload bytes that make each ICMP or UDP
+// probe packetSize bytes long including the IP and transport headers
+func (t *nativeTracer) payloadSize() int {
+	headers := 20 + 8
+	if t.ipv6 {
+		headers = 40 + 8
+	}
+	if t.packetSize <= headers {
+		return 0
+	}
+	return t.packetSize - headers
+}
+
+// probePayload returns size bytes of recognizable probe data
+func probePayload(size int) []byte {
+	const pattern = "NetScout-Go traceroute probe...."
+	payload := make([]byte, size)
+	for i := range payload {
+		payload[i] = pattern[i%len(pattern)]
+	}
+	return payload
+}
+
+// marshalEchoRequest builds an ICMP or ICMPv6 echo request message carrying
+// payloadSize bytes of data
+func marshalEchoRequest(id, seq, payloadSize int, v6 bool) ([]byte, error) {
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: id & 0xffff, Seq: seq & 0xffff, Data: probePayload(payloadSize)},
+	}
+	if v6 {
+		// The kernel fills in the ICMPv6 checksum using the pseudo-header
+		msg.Type = ipv6.ICMPTypeEchoRequest
+	}
+	return msg.Marshal(nil)
+}
+
+// elapsedMs returns the time since start in fractional milliseconds
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// fragmentationNeeded reports whether an ICMP message says a probe was too
+// big to be forwarded without fragmenting it, and the MTU of the next hop
+// the message gives. Old routers report an MTU of 0.
+func fragmentationNeeded(msg *icmp.Message, raw []byte) (bool, int) {
+	switch body := msg.Body.(type) {
+	case *icmp.PacketTooBig:
+		return true, body.MTU
+	case *icmp.DstUnreach:
+		if msg.Type != ipv4.ICMPTypeDestinationUnreachable || msg.Code != icmpCodeFragmentationNeeded {
+			return false, 0
+		}
+		// icmp.DstUnreach leaves out the RFC 1191 next hop MTU, which
+		// takes the last two bytes of the header
+		return true, int(binary.BigEndian.Uint16(raw[6:8]))
+	}
+	return false, 0
+}
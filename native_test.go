@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"syscall"
+	"testing"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+func TestNativePermissionDenied(t *testing.T) {
+	opened := false
+	listenPacket = func(network, address string) (*icmp.PacketConn, error) {
+		opened = true
+		return nil, &net.OpError{Op: "listen", Net: network, Err: os.NewSyscallError("socket", syscall.EPERM)}
+	}
+	t.Cleanup(func() { listenPacket = icmp.ListenPacket })
+
+	_, err := NewPlugin().Execute(context.Background(), map[string]interface{}{
+		"host":       "192.0.2.10",
+		"useNative":  true,
+		"maxHops":    1,
+		"resolveDNS": false,
+	})
+	if !opened {
+		t.Fatal("the raw socket was not opened through listenPacket")
+	}
+	if err == nil {
+		t.Fatal("expected an error when raw sockets are not permitted")
+	}
+
+	if code := errorCode(err); code != ErrPermissionDenied {
+		t.Errorf("got code %q, want %q", code, ErrPermissionDenied)
+	}
+	if !errors.Is(err, os.ErrPermission) {
+		t.Errorf("error %v does not wrap os.ErrPermission", err)
+	}
+	if got := exitCode(err); got != 3 {
+		t.Errorf("got exit code %d, want 3", got)
+	}
+
+	var traceErr *TracerouteError
+	if !errors.As(err, &traceErr) {
+		t.Fatalf("got %T, want a *TracerouteError", err)
+	}
+	want := []string{"run as root", "add CAP_NET_RAW capability"}
+	if runtime.GOOS == "windows" {
+		want = []string{"run as Administrator"}
+	}
+	want = append(want, "set useNative to false to fall back to the system traceroute")
+	if !slices.Equal(traceErr.Suggestions, want) {
+		t.Errorf("got suggestions %q, want %q", traceErr.Suggestions, want)
+	}
+	if report := errorReport(err); !slices.Equal(report["suggestions"].([]string), want) {
+		t.Errorf("error report carries suggestions %v, want %q", report["suggestions"], want)
+	}
+}
+
+// quotePacket returns the start of a packet to dst as an ICMP error quotes
+// it, the IP header followed by the first eight transport bytes
+func quotePacket(t *testing.T, protocol int, dst net.IP, transport []byte) []byte {
+	t.Helper()
+	if dst.To4() == nil {
+		header := make([]byte, ipv6.HeaderLen)
+		header[0] = 6 << 4
+		binary.BigEndian.PutUint16(header[4:], uint16(len(transport)))
+		header[6], header[7] = byte(protocol), 1
+		copy(header[8:], net.ParseIP("2001:db8::1"))
+		copy(header[24:], dst)
+		return append(header, transport[:8]...)
+	}
+	h := ipv4.Header{
+		Version: ipv4.Version, Len: ipv4.HeaderLen, TotalLen: ipv4.HeaderLen + len(transport),
+		TTL: 1, Protocol: protocol, Src: net.ParseIP("192.0.2.1"), Dst: dst,
+	}
+	header, err := h.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(header, transport[:8]...)
+}
+
+func marshalICMP(t *testing.T, msg icmp.Message) []byte {
+	t.Helper()
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMatchReply(t *testing.T) {
+	target, target6 := net.ParseIP("192.0.2.10"), net.ParseIP("2001:db8::10")
+	echo, err := marshalEchoRequest(0x1234, 5, 32, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo6, err := marshalEchoRequest(0x1234, 5, 32, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udp := []byte{0x82, 0x9a, 0x82, 0x9b, 0, 40, 0, 0} // 33434 to 33435
+	tcp := []byte{0xc3, 0x50, 0x01, 0xbb, 0, 0, 0, 1}  // 50000 to 443
+	mpls := []icmp.Extension{&icmp.MPLSLabelStack{Class: 1, Type: 1, Labels: []icmp.MPLSLabel{{Label: 24001, TC: 2, S: true, TTL: 1}}}}
+
+	tests := []struct {
+		name     string
+		protocol string
+		reply    []byte
+		wantType ipv4.ICMPType
+		want     bool
+	}{
+		{"echo reply", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 0x1234, Seq: 5}}), ipv4.ICMPTypeEchoReply, true},
+		{"echo reply to an earlier probe", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 0x1234, Seq: 4}}), 0, false},
+		{"echo reply of another process", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 0x4321, Seq: 5}}), 0, false},
+		{"time exceeded", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotePacket(t, ipProtocolICMP, target, echo)}}), ipv4.ICMPTypeTimeExceeded, true},
+		{"time exceeded with MPLS", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotePacket(t, ipProtocolICMP, target, echo), Extensions: mpls}}), ipv4.ICMPTypeTimeExceeded, true},
+		{"time exceeded for another target", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotePacket(t, ipProtocolICMP, net.ParseIP("192.0.2.99"), echo)}}), 0, false},
+		{"time exceeded for a UDP probe", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotePacket(t, ipProtocolUDP, target, udp)}}), 0, false},
+		{"port unreachable", "udp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Code: 3, Body: &icmp.DstUnreach{Data: quotePacket(t, ipProtocolUDP, target, udp)}}), ipv4.ICMPTypeDestinationUnreachable, true},
+		{"time exceeded for a TCP probe", "tcp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotePacket(t, ipProtocolTCP, target, tcp)}}), ipv4.ICMPTypeTimeExceeded, true},
+		{"truncated quote", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotePacket(t, ipProtocolICMP, target, echo)[:24]}}), 0, false},
+		{"router solicitation", "icmp", marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeRouterSolicitation, Body: &icmp.RawBody{Data: []byte{0, 0, 0, 0}}}), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &nativeTracer{
+				traceOptions: traceOptions{protocol: tt.protocol, port: 443},
+				dst:          &net.IPAddr{IP: target},
+				id:           0x1234,
+			}
+			if tt.protocol == "udp" {
+				tracer.port = 33435
+			}
+			msg, err := icmp.ParseMessage(ipProtocolICMP, tt.reply)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotType, got := tracer.matchReply(msg, 5, 33434)
+			if got != tt.want || (tt.want && gotType != tt.wantType) {
+				t.Errorf("got %v, %v; want %v, %v", gotType, got, tt.wantType, tt.want)
+			}
+		})
+	}
+
+	t.Run("ipv6", func(t *testing.T) {
+		tracer := &nativeTracer{traceOptions: traceOptions{protocol: "icmp"}, dst: &net.IPAddr{IP: target6}, ipv6: true, id: 0x1234}
+		for _, tt := range []struct {
+			reply    icmp.Message
+			wantType ipv4.ICMPType
+		}{
+			{icmp.Message{Type: ipv6.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 0x1234, Seq: 5}}, ipv4.ICMPTypeEchoReply},
+			{icmp.Message{Type: ipv6.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotePacket(t, ipProtocolICMPv6, target6, echo6)}}, ipv4.ICMPTypeTimeExceeded},
+			{icmp.Message{Type: ipv6.ICMPTypeDestinationUnreachable, Code: 4, Body: &icmp.DstUnreach{Data: quotePacket(t, ipProtocolICMPv6, target6, echo6)}}, ipv4.ICMPTypeDestinationUnreachable},
+		} {
+			msg, err := icmp.ParseMessage(ipProtocolICMPv6, marshalICMP(t, tt.reply))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotType, ok := tracer.matchReply(msg, 5, 0); !ok || gotType != tt.wantType {
+				t.Errorf("%v: got %v, %v; want %v, true", tt.reply.Type, gotType, ok, tt.wantType)
+			}
+			if needed, _ := fragmentationNeeded(msg, nil); needed {
+				t.Errorf("%v: reported as fragmentation needed", tt.reply.Type)
+			}
+		}
+	})
+}
+
+func TestParseMPLSLabels(t *testing.T) {
+	reply := marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{
+		Data: quotePacket(t, ipProtocolUDP, net.ParseIP("192.0.2.10"), []byte{0x82, 0x9a, 0x82, 0x9b, 0, 40, 0, 0}),
+		Extensions: []icmp.Extension{&icmp.MPLSLabelStack{Class: 1, Type: 1, Labels: []icmp.MPLSLabel{
+			{Label: 24001, TC: 2, TTL: 1},
+			{Label: 16, S: true, TTL: 255},
+		}}},
+	}})
+	msg, err := icmp.ParseMessage(ipProtocolICMP, reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MPLSLabel{{Label: 24001, Exp: 2, TTL: 1}, {Label: 16, Stack: true, TTL: 255}}
+	if got := parseMPLSLabels(msg); !reflect.DeepEqual(got, want) {
+		t.Errorf("got labels %+v, want %+v", got, want)
+	}
+}
+
+func TestFragmentationNeeded(t *testing.T) {
+	target := net.ParseIP("192.0.2.10")
+	echo, err := marshalEchoRequest(0x1234, 5, 1472, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Code: icmpCodeFragmentationNeeded, Body: &icmp.DstUnreach{Data: quotePacket(t, ipProtocolICMP, target, echo)}})
+	binary.BigEndian.PutUint16(reply[6:], 1400)
+	msg, err := icmp.ParseMessage(ipProtocolICMP, reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if needed, mtu := fragmentationNeeded(msg, reply); !needed || mtu != 1400 {
+		t.Errorf("got %v with MTU %d, want true with 1400", needed, mtu)
+	}
+
+	tooBig := marshalICMP(t, icmp.Message{Type: ipv6.ICMPTypePacketTooBig, Body: &icmp.PacketTooBig{MTU: 1280, Data: []byte{0}}})
+	msg, err = icmp.ParseMessage(ipProtocolICMPv6, tooBig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if needed, mtu := fragmentationNeeded(msg, tooBig); !needed || mtu != 1280 {
+		t.Errorf("got %v with MTU %d, want true with 1280 for packet too big", needed, mtu)
+	}
+}
+
+func TestSetTOS(t *testing.T) {
+	// Options are set from the dialer Control function, before the socket
+	// is connected
+	dialer := net.Dialer{Control: func(network, address string, raw syscall.RawConn) error {
+		return setTOS(rawSocket{raw: raw}, 0x10, false)
+	}}
+	conn, err := dialer.Dial("udp4", "127.0.0.1:33434")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	tos, err := ipv4.NewConn(conn).TOS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tos != 0x10 {
+		t.Errorf("got TOS %#x, want 0x10", tos)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// ndjsonWriter writes one JSON record per line and flushes after every
+// record so consumers reading from a pipe see each one immediately
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// newNDJSONWriter returns a writer emitting newline-delimited JSON to w
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// Write encodes v on a single line and flushes it
+func (n *ndjsonWriter) Write(v interface{}) error {
+	if err := n.enc.Encode(v); err != nil {
+		return err
+	}
+	return n.buf.Flush()
+}
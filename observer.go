@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+type hopObserverKey struct{}
+
+// withHopObserver returns a context whose traces report each hop to fn as
+// soon as it has been probed. The native implementation reports hops while
+// tracing, the system binary only once its output has been parsed.
+func withHopObserver(ctx context.Context, fn func(HopResult)) context.Context {
+	return context.WithValue(ctx, hopObserverKey{}, fn)
+}
+
+// notifyHop passes a discovered hop to the observer in ctx, if any
+func notifyHop(ctx context.Context, hop HopResult) {
+	if fn, ok := ctx.Value(hopObserverKey{}).(func(HopResult)); ok {
+		fn(hop)
+	}
+}
+
+// hopDiscovery passes the hops of a trace to Config.OnHopDiscovered once
+// their hostname is known. Every hop is resolved on its own goroutine so
+// slow lookups do not hold up probing.
+type hopDiscovery struct {
+	callback   func(HopResult)
+	resolveDNS bool
+	resolver   HopResolver
+	dnsServer  *net.Resolver
+	timeout    time.Duration
+	negative   *negativeDNSCache
+	// negativeTTL is how long negative entries are used
+	negativeTTL time.Duration
+
+	// skipPrivate names hops in the private ranges by their address
+	skipPrivate bool
+
+	// names holds the *dnsEntry per address, the final resolution of the
+	// trace reuses them instead of looking the addresses up again
+	names sync.Map
+	wg    sync.WaitGroup
+}
+
+// observe returns a context reporting hops to the discovery as well as to
+// any observer already in ctx
+func (d *hopDiscovery) observe(ctx context.Context) context.Context {
+	next, _ := ctx.Value(hopObserverKey{}).(func(HopResult))
+	return withHopObserver(ctx, func(hop HopResult) {
+		if next != nil {
+			next(hop)
+		}
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			if d.resolveDNS && hop.IP != "*" {
+				if d.resolver != nil {
+					hop = ResolveHops(ctx, []HopResult{hop}, d.resolver)[0]
+				} else if d.skipPrivate && isPrivateIP(net.ParseIP(hop.IP)) {
+					hop.Name = hop.IP
+				} else {
+					entry, _ := d.names.LoadOrStore(hop.IP, &dnsEntry{})
+					hop.Name = entry.(*dnsEntry).lookup(ctx, hop.IP, d.dnsServer, d.timeout, d.negative, d.negativeTTL)
+				}
+			}
+			d.callback(hop)
+		}()
+	})
+}
+
+// wait blocks until the callback has returned for every hop
+func (d *hopDiscovery) wait() {
+	d.wg.Wait()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+// Protocol is the protocol of the probes
+type Protocol string
+
+// The protocols probes can be sent with
+const (
+	ProtocolICMP Protocol = "icmp"
+	ProtocolUDP  Protocol = "udp"
+	ProtocolTCP  Protocol = "tcp"
+)
+
+// Option changes one setting of a Config
+type Option func(*Config)
+
+// WithMaxHops sets the maximum number of hops probed
+func WithMaxHops(n int) Option {
+	return func(cfg *Config) {
+		cfg.DefaultMaxHops = n
+	}
+}
+
+// WithProbeCount sets the number of probes sent per hop
+func WithProbeCount(n int) Option {
+	return func(cfg *Config) {
+		cfg.DefaultProbeCount = n
+	}
+}
+
+// WithProtocol sets the protocol of the probes
+func WithProtocol(p Protocol) Option {
+	return func(cfg *Config) {
+		cfg.DefaultProtocol = string(p)
+	}
+}
+
+// WithASNLookup looks up the autonomous system of each hop, see includeASN
+func WithASNLookup() Option {
+	return func(cfg *Config) {
+		setDefault(cfg, "includeASN", true)
+	}
+}
+
+// WithGeoIP looks up the location of each hop in the GeoIP database at path
+func WithGeoIP(path string) Option {
+	return func(cfg *Config) {
+		cfg.GeoIPDBPath = path
+	}
+}
+
+// WithMaxHistory sets how many iteration results are kept, 0 keeps all
+func WithMaxHistory(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxHistory = n
+	}
+}
+
+// WithDNSServer sends the DNS lookups to addr, an ip:port address, see
+// dnsServer
+func WithDNSServer(addr string) Option {
+	return func(cfg *Config) {
+		setDefault(cfg, "dnsServer", addr)
+	}
+}
+
+// WithSyslogTarget sends every result to the syslog server at target, see
+// syslogTarget
+func WithSyslogTarget(target string) Option {
+	return func(cfg *Config) {
+		setDefault(cfg, "syslogTarget", target)
+	}
+}
+
+// WithMaxConcurrency sets how many targets ExecuteBatch traces at once
+func WithMaxConcurrency(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxConcurrency = n
+	}
+}
+
+// WithOnHopCallback calls fn with every hop of a native trace as soon as it
+// has been probed, see Config.OnHopDiscovered
+func WithOnHopCallback(fn func(HopResult)) Option {
+	return func(cfg *Config) {
+		cfg.OnHopDiscovered = fn
+	}
+}
+
+// setDefault sets a parameter default of cfg without changing the map cfg
+// was given
+func setDefault(cfg *Config, key string, value interface{}) {
+	defaults := maps.Clone(cfg.Defaults)
+	if defaults == nil {
+		defaults = map[string]interface{}{}
+	}
+	defaults[key] = value
+	cfg.Defaults = defaults
+}
+
+// Traceroute traces the path to host with the plugin defaults changed by
+// opts. It is the simplest way to run a single trace:
+//
+//	result, err := Traceroute(ctx, "example.com", WithMaxHops(15), WithProtocol(ProtocolTCP))
+func Traceroute(ctx context.Context, host string, opts ...Option) (TracerouteResult, error) {
+	result, err := NewPlugin(opts...).Execute(ctx, map[string]interface{}{"host": host})
+	if err != nil {
+		return TracerouteResult{}, err
+	}
+	traceResult, ok := result.(TracerouteResult)
+	if !ok {
+		return TracerouteResult{}, fmt.Errorf("unexpected result of type %T", result)
+	}
+	return traceResult, nil
+}
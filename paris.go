@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// parisSourcePortBase is added to the flow ID to form the fixed UDP source
+// port of a Paris traceroute flow
+const parisSourcePortBase = 33000
+
+// Paris traceroute keeps every header field that load balancers hash on
+// constant for the whole trace, so all probes of a flow follow the same
+// path through ECMP routers. Probes are told apart by fields outside the
+// flow hash instead: the UDP checksum for UDP probes, and the ICMP sequence
+// number for echo requests, whose identifier is lowered by the same amount
+// so the ICMP checksum does not change.
+
+// parisSourcePort returns the UDP source port of a flow
+func parisSourcePort(flowID int) int {
+	return parisSourcePortBase + flowID
+}
+
+// echoID returns the ICMP identifier of the probe with the given sequence
+// number
+func (t *nativeTracer) echoID(seq int) int {
+	if t.paris {
+		return (t.id - seq) & 0xffff
+	}
+	return t.id
+}
+
+// parisUDPChecksum returns the checksum identifying a UDP probe. Zero and
+// 0xffff are avoided since a zero checksum is sent as 0xffff.
+func parisUDPChecksum(seq int) uint16 {
+	return uint16(0x1000 + seq%0xe000)
+}
+
+// parisUDPPayload returns a payload of size bytes whose first two bytes are
+// chosen so that the UDP datagram ends up with the given checksum
+func parisUDPPayload(size int, src, dst net.IP, srcPort, dstPort int, checksum uint16) []byte {
+	payload := probePayload(size)
+	payload[0], payload[1] = 0, 0
+
+	udpLength := 8 + size
+	var pseudo []byte
+	if ip4 := src.To4(); ip4 != nil && dst.To4() != nil {
+		pseudo = append(pseudo, ip4...)
+		pseudo = append(pseudo, dst.To4()...)
+		pseudo = append(pseudo, 0, ipProtocolUDP)
+		pseudo = binary.BigEndian.AppendUint16(pseudo, uint16(udpLength))
+	} else {
+		pseudo = append(pseudo, src.To16()...)
+		pseudo = append(pseudo, dst.To16()...)
+		pseudo = binary.BigEndian.AppendUint32(pseudo, uint32(udpLength))
+		pseudo = append(pseudo, 0, 0, 0, ipProtocolUDP)
+	}
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint16(header[0:], uint16(srcPort))
+	binary.BigEndian.PutUint16(header[2:], uint16(dstPort))
+	binary.BigEndian.PutUint16(header[4:], uint16(udpLength))
+
+	sum := onesComplementSum(pseudo, 0)
+	sum = onesComplementSum(header, sum)
+	sum = onesComplementSum(payload, sum)
+
+	// The checksum is the complement of the sum, so the sum including the
+	// adjustment word must equal ^checksum
+	word := onesComplementAdd(^checksum, ^uint16(sum))
+	binary.BigEndian.PutUint16(payload, word)
+	return payload
+}
+
+// onesComplementSum adds b to sum as 16-bit big-endian words and folds the
+// result
+func onesComplementSum(b []byte, sum uint32) uint32 {
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return sum
+}
+
+func onesComplementAdd(a, b uint16) uint16 {
+	sum := uint32(a) + uint32(b)
+	return uint16(sum&0xffff + sum>>16)
+}
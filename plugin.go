@@ -2,241 +2,1418 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"math"
 	"net"
 	"os"
 	"os/exec"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// pluginVersion is the plugin release, kept in sync with plugin.json
+	pluginVersion = "1.0.0"
+
+	// schemaVersion is the version of the result format. Increment it when
+	// a result field is removed or renamed.
+	schemaVersion = "2"
 )
 
 // TraceroutePlugin is the main plugin struct
 type TraceroutePlugin struct {
-	Results        []interface{}
+	Results        []TracerouteResult
 	StartTime      time.Time
 	IterationCount int
+	Config         Config
+
+	// Resolver, when set, replaces the built-in reverse DNS lookups of
+	// resolveDNS
+	Resolver HopResolver
+
+	// FlapHistory holds the most recent hop address changes between
+	// iterations, oldest first
+	FlapHistory []FlapEvent
+
+	asnCache         asnCache
+	negativeDNSCache negativeDNSCache
+	geoIP            geoIPCache
+	rolling          map[int][]rollingSample
+
+	// availability counts the probes per hop number over all iterations
+	availability map[int]HopAvailability
+
+	// historyPath is the history file the results were restored from
+	historyPath string
+
+	alertStreaks map[int]int
+
+	// SLA breaches over the iterations, see recordSLA
+	slaBreachCount       int
+	slaBreachPeakPercent float64
+
+	// cache holds recent single trace results, see cacheEnabled
+	cache resultCache
+
+	// asyncNames holds the hostnames of asyncDNS traces, see Flush
+	asyncNames asyncNames
+
+	// iterationWaitTime is the waitTime in seconds last passed to an
+	// iteration, used by the following iterations that pass none
+	iterationWaitTime *float64
+}
+
+// NewPlugin creates a new plugin instance with the DefaultConfig changed
+// by opts, such as NewPlugin(WithMaxHistory(50), WithDNSServer("10.0.0.53:53")).
+// Calling it without options is kept for compatibility, new code should
+// pass the settings it relies on rather than build a Config.
+func NewPlugin(opts ...Option) *TraceroutePlugin {
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewPluginWithConfig(cfg)
+}
+
+// Reset clears the iteration state while keeping the configuration
+func (p *TraceroutePlugin) Reset() {
+	p.IterationCount = 0
+	p.Results = []TracerouteResult{}
+	p.StartTime = time.Now()
+	p.rolling = nil
+	p.availability = nil
+	p.alertStreaks = nil
+	p.FlapHistory = nil
+	p.slaBreachCount = 0
+	p.slaBreachPeakPercent = 0
+	p.iterationWaitTime = nil
+}
+
+// SetMaxHistory limits how many iteration results are kept, dropping the
+// oldest first. A value of 0 keeps every result.
+func (p *TraceroutePlugin) SetMaxHistory(n int) {
+	if n < 0 {
+		n = 0
+	}
+	p.Config.MaxHistory = n
+	p.trimHistory(n)
+}
+
+// trimHistory drops the oldest results beyond limit
+func (p *TraceroutePlugin) trimHistory(limit int) {
+	if limit > 0 && len(p.Results) > limit {
+		p.Results = append([]TracerouteResult{}, p.Results[len(p.Results)-limit:]...)
+	}
 }
 
-// NewPlugin creates a new plugin instance
-func NewPlugin() *TraceroutePlugin {
-	return &TraceroutePlugin{
-		StartTime: time.Now(),
-		Results:   []interface{}{},
+// Execute handles the traceroute plugin execution, the context bounds the
+// whole run and cancelling it stops any trace in progress
+func (p *TraceroutePlugin) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	params = p.withParamDefaults(params)
+	rawHost, _ := params["host"].(string)
+	params, err := p.prepareParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Spans join the trace of the caller, if any, and are exported when a
+	// collector is configured
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	if otlpEndpoint, _ := params["otlpEndpoint"].(string); otlpEndpoint != "" {
+		provider, err := newTracerProvider(ctx, otlpEndpoint)
+		if err != nil {
+			return nil, paramError("otlpEndpoint", err.Error())
+		}
+		tracer = provider.Tracer(tracerName)
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+			defer cancel()
+			if err := provider.Shutdown(shutdownCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to export spans: %v\n", err)
+			}
+		}()
+	}
+
+	// Tag the result so it can be matched with its request downstream
+	executionID := newExecutionID()
+	correlationID, _ := params["correlationID"].(string)
+
+	host, _ := params["host"].(string)
+	ctx, span := tracer.Start(ctx, "traceroute.execute", trace.WithAttributes(
+		attribute.String("host", host),
+		attribute.String("traceroute.execution_id", executionID)))
+	result, err := p.execute(ctx, params)
+	err = classifyError(err, ErrCommandFailed)
+	endSpan(span, err)
+	if traceResult, ok := result.(TracerouteResult); ok {
+		traceResult.ExecutionID = executionID
+		traceResult.CorrelationID = correlationID
+		traceResult.PluginVersion = pluginVersion
+		traceResult.SchemaVersion = schemaVersion
+		if host != rawHost {
+			traceResult.NormalizedHost = host
+		}
+		result = traceResult
+		if syslogTarget, _ := params["syslogTarget"].(string); syslogTarget != "" && traceResult.DryRun == "" {
+			syslogFacility, _ := params["syslogFacility"].(string)
+			if err := sendSyslog(syslogTarget, syslogFacility, traceResult); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		if influx := influxWriterFromParams(params); influx != nil && traceResult.DryRun == "" {
+			if err := influx.write(traceResult); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
 	}
+	return result, err
+}
+
+// newExecutionID returns a random UUID version 4 string
+func newExecutionID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40 // Version 4
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
 }
 
-// Execute handles the traceroute plugin execution
-func (p *TraceroutePlugin) Execute(params map[string]interface{}) (interface{}, error) {
+// execute runs a single trace or an iteration under the overall timeout
+func (p *TraceroutePlugin) execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	in := paramReader{params: params}
+
+	// Bound the whole run by the overall timeout, if one was given
+	timeout := p.Config.DefaultTimeout
+	if overallTimeout, ok := in.float("overallTimeout"); ok {
+		timeout = time.Duration(overallTimeout * float64(time.Second))
+	}
+	continueToIterate, _ := in.bool("continueToIterate")
+	cacheEnabled, _ := in.bool("cacheEnabled")
+	cacheTTL := defaultCacheTTL
+	if cacheTTLParam, ok := in.float("cacheTTL"); ok && cacheTTLParam > 0 {
+		cacheTTL = time.Duration(cacheTTLParam * float64(time.Second))
+	}
+	maxHops, ok := in.int("maxHops")
+	if !ok {
+		maxHops = p.Config.DefaultMaxHops
+	}
+	dryRun, _ := in.bool("dryRun")
+	if in.err != nil {
+		return nil, in.err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	// Check if we should use iteration
-	continueToIterate, _ := params["continueToIterate"].(bool)
 	if continueToIterate {
-		return p.executeWithIteration(params)
+		result, err := p.executeWithIteration(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	// Answer repeated single traces of a target from the cache
+	cacheEnabled = cacheEnabled && !dryRun
+	var cacheKey resultCacheKey
+	if cacheEnabled {
+		cacheKey.host, _ = params["host"].(string)
+		cacheKey.maxHops = maxHops
+		cacheKey.protocol, _ = params["protocol"].(string)
+		if cacheKey.protocol == "" {
+			cacheKey.protocol = p.Config.DefaultProtocol
+		}
+		if result, ok := p.cache.get(cacheKey); ok {
+			return result, nil
+		}
 	}
 
 	// Run a single execution
-	return p.performTraceroute(params)
+	result, err := p.performTraceroute(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result.ElapsedTime = time.Since(p.StartTime)
+	if cacheEnabled {
+		p.cache.put(cacheKey, result, cacheTTL)
+	}
+	return result, nil
 }
 
 // executeWithIteration handles running the plugin in iteration mode
-func (p *TraceroutePlugin) executeWithIteration(params map[string]interface{}) (interface{}, error) {
+func (p *TraceroutePlugin) executeWithIteration(ctx context.Context, params map[string]interface{}) (TracerouteResult, error) {
+	in := paramReader{params: params}
+	maxHistory := p.Config.MaxHistory
+	if maxHistoryParam, ok := in.float("maxHistory"); ok && maxHistoryParam >= 0 {
+		maxHistory = int(maxHistoryParam)
+	}
+	rollingWindow := p.Config.RollingWindow
+	if rollingWindowParam, ok := in.float("rollingWindow"); ok && rollingWindowParam >= 1 {
+		rollingWindow = int(rollingWindowParam)
+	}
+	if rollingWindow < 1 {
+		rollingWindow = defaultRollingWindow
+	}
+	includeRollingStats, _ := in.bool("includeRollingStats")
+	includeAvailability, _ := in.bool("includeAvailability")
+	convergenceWindow, ok := in.int("convergenceWindow")
+	if !ok || convergenceWindow < 2 {
+		convergenceWindow = defaultConvergenceWindow
+	}
+	rttTolerance, ok := in.float("rttTolerance")
+	if !ok || rttTolerance < 0 {
+		rttTolerance = defaultRTTTolerance
+	}
+	historyFile, ok := params["historyFile"].(string)
+	if !ok {
+		historyFile = p.Config.HistoryFile
+	}
+
+	// The wait time is kept for the following iterations, which trace with
+	// it unless they pass their own
+	if waitTime, ok := in.float("waitTime"); ok && waitTime >= 0 {
+		p.iterationWaitTime = &waitTime
+	} else if !ok && p.iterationWaitTime != nil {
+		params = maps.Clone(params)
+		params["waitTime"] = *p.iterationWaitTime
+	}
+	if in.err != nil {
+		return TracerouteResult{}, in.err
+	}
+
+	// Pick up where a previous process left off
+	if historyFile != "" && historyFile != p.historyPath {
+		if err := p.restoreHistory(historyFile); err != nil {
+			return TracerouteResult{}, err
+		}
+	}
+
 	// Run the traceroute operation
-	result, err := p.performTraceroute(params)
+	result, err := p.performTraceroute(ctx, params)
 	if err != nil {
-		return nil, err
+		return TracerouteResult{}, err
+	}
+
+	// Compare against the previous iteration before storing this one
+	if len(p.Results) > 0 {
+		result.ChangedHops = changedHops(p.Results[len(p.Results)-1], result)
+		result.PathChanged = len(result.ChangedHops) > 0
+		p.recordFlaps(p.Results[len(p.Results)-1], result)
 	}
 
-	// Update state
+	// Update state, the stored copy does not carry iteration metadata
 	p.IterationCount++
-	if resultMap, ok := result.(map[string]interface{}); ok {
-		// Create a copy of the result for history to avoid reference issues
-		historyCopy := make(map[string]interface{})
-		for k, v := range resultMap {
-			historyCopy[k] = v
-		}
-		p.Results = append(p.Results, historyCopy)
-
-		// Add iteration metadata to the result
-		resultMap["iterationCount"] = p.IterationCount
-		resultMap["elapsedTime"] = time.Since(p.StartTime).String()
-
-		// Create a summary for the UI
-		host := resultMap["host"].(string)
-		if hops, ok := resultMap["hops"].([]map[string]interface{}); ok {
-			hopCount := len(hops)
-			var lastHopIP string
-			if hopCount > 0 {
-				if ip, ok := hops[hopCount-1]["ip"].(string); ok {
-					lastHopIP = ip
-				} else if host, ok := hops[hopCount-1]["host"].(string); ok {
-					lastHopIP = host
-				}
-			}
+	p.Results = append(p.Results, result)
+	if historyFile != "" {
+		stored := result
+		stored.IterationCount = p.IterationCount
+		if err := appendHistory(historyFile, stored); err != nil {
+			return TracerouteResult{}, err
+		}
+	}
 
-			// Add iteration_data for UI display
-			resultMap["iteration_data"] = map[string]interface{}{
-				"can_iterate":        true,
-				"supports_iteration": true,
-				"iteration_summary": fmt.Sprintf(
-					"Iteration %d: %s - %d hops, final: %s",
-					p.IterationCount,
-					host,
-					hopCount,
-					lastHopIP,
-				),
-			}
-		}
-
-		// Add history summary
-		if len(p.Results) > 1 {
-			history := make([]map[string]interface{}, 0)
-			for i, res := range p.Results {
-				if resMap, ok := res.(map[string]interface{}); ok {
-					// Create a simplified history entry
-					host := resMap["host"].(string)
-					timestamp := resMap["timestamp"].(string)
-
-					var hopCount int
-					var lastHopIP string
-					if hops, ok := resMap["hops"].([]map[string]interface{}); ok {
-						hopCount = len(hops)
-						if hopCount > 0 {
-							if ip, ok := hops[hopCount-1]["ip"].(string); ok {
-								lastHopIP = ip
-							} else if host, ok := hops[hopCount-1]["host"].(string); ok {
-								lastHopIP = host
-							}
-						}
-					}
-
-					historyEntry := map[string]interface{}{
-						"iteration": i + 1,
-						"timestamp": timestamp,
-						"host":      host,
-						"hopCount":  hopCount,
-						"lastHop":   lastHopIP,
-					}
-					history = append(history, historyEntry)
-				}
+	p.trimHistory(maxHistory)
+
+	result.RTTAnomalies = p.rttAnomalies(result)
+	p.recordRolling(result, rollingWindow)
+	p.updateAlertStreaks(result.Alerts)
+	if result.SLAViolations != nil {
+		p.recordSLA(result.SLAViolations)
+		result.SLABreachCount = p.slaBreachCount
+		result.SLABreachPeakPercent = p.slaBreachPeakPercent
+	}
+	p.recordAvailability(result)
+	result.RecentFlaps = p.recentFlaps(p.Config.FlapThreshold)
+	result.FlapCount = len(result.RecentFlaps)
+	if stable := stableIterations(p.Results, rttTolerance); stable >= convergenceWindow {
+		result.Converged = true
+		result.ConvergenceIterations = stable
+	}
+	if includeAvailability {
+		result.HopAvailability = p.HopAvailability()
+	}
+	if includeRollingStats {
+		result.RollingStats = p.rollingStats(rollingWindow)
+	}
+
+	// Add iteration metadata to the result
+	result.IterationCount = p.IterationCount
+	result.ElapsedTime = time.Since(p.StartTime)
+
+	// Add iteration_data for UI display
+	result.IterationData = &IterationData{
+		CanIterate:        true,
+		SupportsIteration: true,
+		HistorySize:       len(p.Results),
+		MaxHistory:        maxHistory,
+		IterationSummary: fmt.Sprintf(
+			"Iteration %d: %s - %d hops, final: %s",
+			p.IterationCount,
+			result.Host,
+			len(result.Hops),
+			result.lastHopIP(),
+		),
+	}
+
+	// Add history summary
+	if len(p.Results) > 1 {
+		history := make([]HistoryEntry, 0, len(p.Results))
+		for i, res := range p.Results {
+			hopLoss := make([]HopLoss, 0, len(res.Hops))
+			for _, hop := range res.Hops {
+				hopLoss = append(hopLoss, HopLoss{Hop: hop.Hop, Loss: hop.Loss})
 			}
-			resultMap["history"] = history
+
+			// Hop addresses are only comparable within the same family
+			history = append(history, HistoryEntry{
+				Iteration:     p.IterationCount - len(p.Results) + i + 1,
+				Timestamp:     res.Timestamp,
+				Host:          res.Host,
+				AddressFamily: res.AddressFamily,
+				HopCount:      len(res.Hops),
+				LastHop:       res.lastHopIP(),
+				HopLoss:       hopLoss,
+
+				CommandDurationMs: res.CommandDurationMs,
+				DNSDurationMs:     res.DNSDurationMs,
+				ParseDurationMs:   res.ParseDurationMs,
+			})
 		}
+		result.History = history
 	}
 
 	return result, nil
 }
 
+// changedHops returns the hop numbers whose responding address differs
+// between two runs. Hops that did not answer in either run are ignored so
+// ICMP rate limiting does not show up as a path change, and paths traced
+// over different address families are never considered equal.
+func changedHops(previous, current TracerouteResult) []int {
+	changed := []int{}
+	previousIPs := previous.hopIPs()
+	currentIPs := current.hopIPs()
+	familyChanged := previous.AddressFamily != current.AddressFamily
+
+	seen := map[int]bool{}
+	for _, res := range []TracerouteResult{previous, current} {
+		for _, hop := range res.Hops {
+			if seen[hop.Hop] {
+				continue
+			}
+			seen[hop.Hop] = true
+
+			before, hadBefore := previousIPs[hop.Hop]
+			after, hasAfter := currentIPs[hop.Hop]
+			if familyChanged || (hadBefore && hasAfter && before != after) {
+				changed = append(changed, hop.Hop)
+			}
+		}
+	}
+
+	sort.Ints(changed)
+	return changed
+}
+
+// traceOptions holds the resolved parameters of a single trace
+type traceOptions struct {
+	host       string
+	target     net.IP
+	firstHop   int
+	maxHops    int
+	probeCount int
+	protocol   string
+	port       int
+	packetSize int
+	tos        int
+	waitTime   time.Duration
+	source     net.IP
+
+	sourceInterface string
+	continueOnLoop  bool
+	paris           bool
+	flowID          int
+	dontFragment    bool
+	retryTimeouts   int
+
+	// sourcePort is the local port of TCP and UDP probes, 0 lets the
+	// system pick one
+	sourcePort int
+}
+
+// probePorts returns the source and destination ports of the probes, the
+// source port is 0 when the system picks it and both are 0 for ICMP
+func probePorts(opts traceOptions, protocol string) (int, int) {
+	if protocol == "icmp" {
+		return 0, 0
+	}
+	if opts.paris && protocol == "udp" {
+		return parisSourcePort(opts.flowID), opts.port
+	}
+	return opts.sourcePort, opts.port
+}
+
 // performTraceroute handles the actual traceroute logic
-func (p *TraceroutePlugin) performTraceroute(params map[string]interface{}) (interface{}, error) {
+func (p *TraceroutePlugin) performTraceroute(ctx context.Context, params map[string]interface{}) (TracerouteResult, error) {
+	in := paramReader{params: params}
+	if discoverAllPaths, _ := in.bool("discoverAllPaths"); discoverAllPaths {
+		return p.discoverPaths(ctx, params)
+	}
+
 	host, _ := params["host"].(string)
-	maxHopsParam, ok := params["maxHops"].(float64)
+	maxHopsParam, ok := in.float("maxHops")
 	if !ok {
-		maxHopsParam = 30 // Default max hops
+		maxHopsParam = float64(p.Config.DefaultMaxHops)
 	}
 	maxHops := int(maxHopsParam)
+	if maxHops < 1 {
+		maxHops = 30 // Default max hops
+	}
+	firstHopParam, ok := in.float("firstHop")
+	if !ok {
+		firstHopParam = 1 // Start probing at the first router
+	}
+	firstHop := int(firstHopParam)
+	packetSizeParam, hasPacketSize := in.float("packetSize")
+	tosParam, _ := in.float("tos")
+	tos := int(tosParam)
+	waitTime := p.Config.WaitTime
+	if waitTimeParam, ok := in.float("waitTime"); ok {
+		waitTime = time.Duration(waitTimeParam * float64(time.Second))
+	}
+	protocol, ok := params["protocol"].(string)
+	if !ok {
+		protocol = p.Config.DefaultProtocol
+	}
+	portParam, ok := in.float("port")
+	if !ok {
+		portParam = 80 // Default probe port
+	}
+	port := int(portParam)
+	if destPortParam, ok := in.float("destPort"); ok {
+		port = int(destPortParam)
+	}
+	sourcePortParam, _ := in.float("sourcePort")
+	sourcePort := int(sourcePortParam)
+	probeCountParam, ok := in.float("probeCount")
+	if !ok {
+		probeCountParam = float64(p.Config.DefaultProbeCount)
+	}
+	probeCount := int(probeCountParam)
+	if probeCount < 1 {
+		probeCount = 3 // Default probes per hop
+	}
+	useNative, _ := in.bool("useNative")
+	sourceAddress, _ := params["sourceAddress"].(string)
+	sourceInterface, _ := params["sourceInterface"].(string)
+	interfaceAutoSelect, _ := params["interfaceAutoSelect"].(string)
+	resolveDNS, ok := in.bool("resolveDNS")
+	if !ok {
+		resolveDNS = p.Config.ResolveDNS
+	}
+	dnsParallelism, ok := in.float("dnsParallelism")
+	if !ok {
+		dnsParallelism = float64(p.Config.DNSParallelism)
+	}
+	dnsTimeout, ok := in.float("dnsTimeout")
+	if !ok {
+		dnsTimeout = defaultDNSTimeout.Seconds()
+	}
+	negativeCacheTTL := p.Config.NegativeCacheTTL
+	if negativeCacheTTLParam, ok := in.float("negativeCacheTTL"); ok {
+		negativeCacheTTL = time.Duration(negativeCacheTTLParam * float64(time.Second))
+	}
+	dnsServer, _ := params["dnsServer"].(string)
+	var dnsResolver *net.Resolver
+	if dnsServer != "" {
+		dnsResolver = newDNSResolver(dnsServer)
+	}
+	skipPrivateDNS, _ := in.bool("skipPrivateDNS")
+	asyncDNS, _ := in.bool("asyncDNS")
+	includeASN, _ := in.bool("includeASN")
+	continueOnLoop, _ := in.bool("continueOnLoop")
+	paris, _ := in.bool("parisTraceroute")
+	mtuDiscovery, _ := in.bool("mtuDiscovery")
+	dontFragment, _ := in.bool("dontFragment")
+	flowID, _ := in.int("flowID")
+	retryTimeouts, _ := in.int("retryTimeouts")
+	sla, err := slaParam(params)
+	if err != nil {
+		return TracerouteResult{}, err
+	}
+	rttThreshold, _ := in.float("rttThreshold")
+	lossThreshold, _ := in.float("lossThreshold")
+	dryRun, _ := in.bool("dryRun")
+	includeRawOutput, ok := in.bool("includeRawOutput")
+	if !ok {
+		includeRawOutput = true
+	}
+	geoipDBPath, ok := params["geoipDBPath"].(string)
+	if !ok {
+		geoipDBPath = p.Config.GeoIPDBPath
+	}
+	if in.err != nil {
+		return TracerouteResult{}, in.err
+	}
 
 	if host == "" {
-		return nil, fmt.Errorf("host parameter is required")
+		return TracerouteResult{}, paramError("host", "is required")
+	}
+
+	if firstHop < 1 || firstHop > maxHops {
+		return TracerouteResult{}, paramError("firstHop", fmt.Sprintf("must be between 1 and maxHops (%d), got %d", maxHops, firstHop))
+	}
+
+	if tos < 0 || tos > 255 {
+		return TracerouteResult{}, paramError("tos", fmt.Sprintf("must fit in a byte (0-255), got %d", tos))
+	}
+
+	if waitTime < 0 {
+		return TracerouteResult{}, paramError("waitTime", fmt.Sprintf("must not be negative, got %v", waitTime.Seconds()))
+	}
+
+	if negativeCacheTTL < 0 {
+		return TracerouteResult{}, paramError("negativeCacheTTL", fmt.Sprintf("must not be negative, got %v", negativeCacheTTL.Seconds()))
+	}
+
+	switch protocol {
+	case "", "icmp", "udp", "tcp":
+	default:
+		return TracerouteResult{}, paramError("protocol", fmt.Sprintf("must be icmp, udp or tcp, got %q", protocol))
+	}
+
+	if mtuDiscovery && protocol == "tcp" {
+		return TracerouteResult{}, paramError("mtuDiscovery", "requires the icmp or udp protocol")
+	}
+
+	if paris && sourcePort != 0 {
+		return TracerouteResult{}, paramError("sourcePort", "can not be combined with parisTraceroute, which sets the source port from flowID")
+	}
+	if paris {
+		if protocol == "tcp" {
+			return TracerouteResult{}, paramError("parisTraceroute", "supports the icmp and udp protocols")
+		}
+		if flowID < 0 || parisSourcePort(flowID) > 65535 {
+			return TracerouteResult{}, paramError("flowID", fmt.Sprintf("must be between 0 and %d, got %d", 65535-parisSourcePortBase, flowID))
+		}
+	}
+
+	if retryTimeouts < 0 || retryTimeouts > maxRetryTimeouts {
+		return TracerouteResult{}, paramError("retryTimeouts", fmt.Sprintf("must be between 0 and %d, got %d", maxRetryTimeouts, retryTimeouts))
+	}
+	// The system binary can only repeat the whole trace, not a single probe
+	if retryTimeouts > 0 && !useNative {
+		return TracerouteResult{}, paramError("retryTimeouts", "requires useNative, the system traceroute can not retry single probes")
+	}
+
+	// Resolve the target up front so we know which address family to trace
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+	// Internationalized names are resolved and traced in their ASCII
+	// compatible form, the result keeps the name as given
+	typedHost := host
+	host, err = idnaToASCII(host)
+	if err != nil {
+		return TracerouteResult{}, paramError("host", fmt.Sprintf("is not a valid internationalized domain name: %v", err))
+	}
+	resolvedHost := ""
+	if host != typedHost {
+		resolvedHost = host
+	}
+
+	dnsStart := time.Now()
+	dnsCtx, dnsSpan := startSpan(ctx, "traceroute.dns_resolution", attribute.String("dns.query", host))
+	target, err := resolveTarget(dnsCtx, host, dnsResolver)
+	endSpan(dnsSpan, err)
+	if err != nil {
+		return TracerouteResult{}, classifyError(err, ErrDNSFailed)
+	}
+	dnsDuration := elapsedMs(dnsStart)
+	addressFamily := "ipv4"
+	if target.To4() == nil {
+		addressFamily = "ipv6"
+	}
+
+	if interfaceAutoSelect != "" {
+		if sourceInterface != "" {
+			return TracerouteResult{}, paramError("interfaceAutoSelect", "can not be combined with sourceInterface")
+		}
+		iface, err := selectInterfaceByCIDR(interfaceAutoSelect)
+		if err != nil {
+			return TracerouteResult{}, classifyError(err, ErrInvalidParam)
+		}
+		_, subnet, _ := net.ParseCIDR(interfaceAutoSelect)
+		if (subnet.IP.To4() == nil) != (addressFamily == "ipv6") {
+			return TracerouteResult{}, paramError("interfaceAutoSelect", fmt.Sprintf("%s does not match the address family of the target", subnet))
+		}
+		sourceInterface = iface.Name
+		if sourceAddress == "" {
+			sourceAddress = addressInSubnet(iface, subnet).String()
+		}
+	}
+	source, err := resolveSource(sourceAddress, sourceInterface, addressFamily == "ipv6")
+	if err != nil {
+		return TracerouteResult{}, classifyError(err, ErrInvalidParam)
+	}
+
+	// IPv6 links must carry at least 1280 bytes, smaller probes are not
+	// meaningful there
+	minPacketSize := 60
+	if addressFamily == "ipv6" {
+		minPacketSize = 1280
+	}
+	packetSize := minPacketSize
+	if hasPacketSize {
+		packetSize = int(packetSizeParam)
+	}
+	if packetSize < minPacketSize || packetSize > 65535 {
+		return TracerouteResult{}, paramError("packetSize", fmt.Sprintf("must be between %d and 65535 for %s, got %d", minPacketSize, addressFamily, packetSize))
+	}
+
+	opts := traceOptions{
+		host:            host,
+		target:          target,
+		firstHop:        firstHop,
+		maxHops:         maxHops,
+		probeCount:      probeCount,
+		protocol:        protocol,
+		port:            port,
+		packetSize:      packetSize,
+		tos:             tos,
+		waitTime:        waitTime,
+		continueOnLoop:  continueOnLoop,
+		paris:           paris,
+		flowID:          flowID,
+		retryTimeouts:   retryTimeouts,
+		dontFragment:    dontFragment,
+		sourcePort:      sourcePort,
+		source:          source,
+		sourceInterface: sourceInterface,
+	}
+
+	if dryRun {
+		command, err := describeTrace(opts, useNative)
+		if err != nil {
+			return TracerouteResult{}, classifyError(err, ErrCommandFailed)
+		}
+		return TracerouteResult{
+			Host:          typedHost,
+			ResolvedHost:  resolvedHost,
+			Hops:          []HopResult{},
+			AddressFamily: addressFamily,
+			PacketSize:    packetSize,
+			TOSUsed:       tos,
+			Timestamp:     time.Now().Truncate(time.Second),
+			DryRun:        command,
+		}, nil
+	}
+
+	// Report hops of native traces to the caller as they are discovered
+	probeCtx := ctx
+	var discovery *hopDiscovery
+	if p.Config.OnHopDiscovered != nil && useNative {
+		discovery = &hopDiscovery{
+			callback:    p.Config.OnHopDiscovered,
+			resolveDNS:  resolveDNS && !asyncDNS,
+			resolver:    p.Resolver,
+			dnsServer:   dnsResolver,
+			negative:    &p.negativeDNSCache,
+			negativeTTL: negativeCacheTTL,
+			skipPrivate: skipPrivateDNS,
+			timeout:     time.Duration(dnsTimeout * float64(time.Second)),
+		}
+		probeCtx = discovery.observe(ctx)
+	}
+	result, err := p.runProbes(probeCtx, opts, useNative)
+	if discovery != nil {
+		discovery.wait()
+	}
+	if err != nil {
+		return TracerouteResult{}, classifyError(err, ErrCommandFailed)
+	}
+
+	result.LoopsDetected = detectLoops(result.Hops)
+	if len(result.LoopsDetected) > 0 && !continueOnLoop {
+		result.Hops = truncateAtLoop(result.Hops, result.LoopsDetected)
+		result.LoopsDetected = detectLoops(result.Hops)
+	}
+	result.HasLoop = len(result.LoopsDetected) > 0
+	markLoops(result.Hops, result.LoopsDetected)
+	for _, hop := range result.Hops {
+		if len(hop.MPLSLabels) > 0 {
+			result.HasMPLS = true
+			break
+		}
+	}
+
+	// The final hop tells whether the trace got through or ran out of hops
+	if len(result.Hops) > 0 {
+		lastHop := result.Hops[len(result.Hops)-1]
+		if result.DestinationReached || isDestination(ctx, host, target, lastHop.IP) {
+			result.DestinationReached = true
+			result.ReachedAtHop = lastHop.Hop
+		} else {
+			result.Truncated = lastHop.Hop >= maxHops
+		}
+	}
+
+	if mtuDiscovery {
+		ceiling := defaultMTUCeiling
+		if hasPacketSize {
+			ceiling = packetSize
+		}
+		result.PathMTU, err = p.discoverMTU(ctx, opts, useNative, result.Hops, minPacketSize, ceiling)
+		if err != nil {
+			return TracerouteResult{}, classifyError(err, ErrCommandFailed)
+		}
+	}
+
+	result.Host = typedHost
+	result.ResolvedHost = resolvedHost
+	result.AddressFamily = addressFamily
+	if !includeRawOutput {
+		result.RawOutput = nil
+	}
+	result.PacketSize = opts.packetSize
+	result.TOSUsed = opts.tos
+	if paris {
+		result.FlowID = flowID
+	}
+
+	// Probes are paced, warn when the pauses alone outlast the timeout
+	if deadline, ok := ctx.Deadline(); ok && waitTime > 0 {
+		probes := (maxHops - firstHop + 1) * probeCount
+		if pause := waitTime * time.Duration(probes); pause > time.Until(deadline) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"waitTime of %v over up to %d probes (%v) exceeds the remaining overall timeout",
+				waitTime, probes, pause))
+		}
+	}
+	result.ChangedHops = []int{}
+	if source != nil {
+		result.SourceAddress = source.String()
+	}
+	if interfaceAutoSelect != "" {
+		result.SelectedInterface = sourceInterface
+		result.SelectedSourceIP = source.String()
+	}
+
+	// Look up hostnames once every hop is known
+	resolve := func(ctx context.Context, hops []HopResult) []HopResult {
+		if p.Resolver != nil {
+			return ResolveHops(ctx, hops, p.Resolver)
+		}
+		var names *sync.Map
+		if discovery != nil {
+			names = &discovery.names
+		}
+		resolveHostnames(ctx, hops, int(dnsParallelism), time.Duration(dnsTimeout*float64(time.Second)), dnsResolver, names, &p.negativeDNSCache, negativeCacheTTL, skipPrivateDNS)
+		return hops
+	}
+	if resolveDNS && asyncDNS {
+		// The hops are named by their address until Flush
+		for i := range result.Hops {
+			if result.Hops[i].IP != "*" {
+				result.Hops[i].Name = result.Hops[i].IP
+			}
+		}
+		result.DNSPending = true
+		p.asyncNames.resolveInBackground(slices.Clone(result.Hops), resolve)
+	} else if resolveDNS {
+		dnsStart := time.Now()
+		dnsCtx, dnsSpan := startSpan(ctx, "traceroute.dns_resolution", attribute.Int("dns.hops", len(result.Hops)))
+		result.Hops = resolve(dnsCtx, result.Hops)
+		dnsSpan.End()
+		dnsDuration += elapsedMs(dnsStart)
+	}
+	if resolveDNS && skipPrivateDNS && p.Resolver == nil {
+		for _, hop := range result.Hops {
+			if hop.IP != "*" && isPrivateIP(net.ParseIP(hop.IP)) {
+				result.SkippedPrivateDNS++
+			}
+		}
+	}
+	result.DNSDurationMs = dnsDuration
+	result.DNSServer = dnsServer
+
+	if includeASN {
+		p.lookupASNs(ctx, result.Hops)
+	}
+
+	if geoipDBPath != "" {
+		if err := p.lookupGeoIP(geoipDBPath, result.Hops); err != nil {
+			return TracerouteResult{}, classifyError(err, ErrInvalidParam)
+		}
+		result.GeoIPEnabled = true
+	}
+
+	result.Alerts = checkThresholds(result.Hops, rttThreshold, lossThreshold)
+	result.HasAlerts = len(result.Alerts) > 0
+	if sla != nil {
+		result.SLAViolations = checkSLA(result, *sla)
+		result.SLABreached = len(result.SLAViolations) > 0
+	}
+
+	span := trace.SpanFromContext(ctx)
+	for _, hop := range result.Hops {
+		span.AddEvent("hop", trace.WithAttributes(
+			attribute.Int("hop.number", hop.Hop),
+			attribute.String("hop.ip", hop.IP),
+			attribute.Float64("hop.rtt_ms", hop.RTTAvg)))
+	}
+
+	return result, nil
+}
+
+// runProbes traces with the raw socket implementation when requested and
+// with the system binary otherwise. Not being allowed to open raw sockets
+// is reported with suggestions rather than silently using the binary.
+func (p *TraceroutePlugin) runProbes(ctx context.Context, opts traceOptions, useNative bool) (TracerouteResult, error) {
+	if useNative {
+		nativeOpts := opts
+		if nativeOpts.protocol == "" {
+			nativeOpts.protocol = "icmp"
+		}
+		return p.performTracerouteNative(ctx, nativeOpts)
+	}
+	return p.performTracerouteExec(ctx, opts)
+}
+
+// tracerouteCommand builds the command line for the given traceroute binary
+// and dialect, returning the binary to run, its arguments and the protocol
+// it will probe with
+func tracerouteCommand(binary string, flavor BinaryFlavor, opts traceOptions) (string, []string, string, error) {
+	var args []string
+	protocol := opts.protocol
+	ipv6 := opts.target.To4() == nil
+	if flavor == FlavorWindows {
+		// tracert only probes with ICMP and always sends three probes
+		protocol = "icmp"
+		args = []string{"-d", "-h", fmt.Sprintf("%d", opts.maxHops)}
+		if ipv6 {
+			args = append(args, "-6")
+		} else {
+			args = append(args, "-4")
+		}
+		if opts.source != nil {
+			args = append(args, "-S", opts.source.String())
+		}
+		if opts.tos != 0 {
+			return "", nil, "", errors.New("tracert cannot set the TOS byte, enable useNative to use tos")
+		}
+		if opts.waitTime > 0 {
+			return "", nil, "", errors.New("tracert cannot pause between probes, enable useNative to use waitTime")
+		}
+		if opts.dontFragment {
+			return "", nil, "", errors.New("tracert cannot set the don't fragment bit, enable useNative to use dontFragment or mtuDiscovery")
+		}
+	} else {
+		// The binary probes with UDP by default
+		args = []string{"-n", "-f", fmt.Sprintf("%d", opts.firstHop), "-m", fmt.Sprintf("%d", opts.maxHops), "-q", fmt.Sprintf("%d", opts.probeCount)}
+		if ipv6 {
+			if path, err := exec.LookPath("traceroute6"); err == nil {
+				binary = path
+			} else {
+				args = append([]string{"-6"}, args...)
+			}
+		}
+		switch protocol {
+		case "icmp":
+			args = append(args, "-I")
+		case "tcp":
+			if flavor == FlavorBSD {
+				args = append(args, "-P", "tcp", "-p", fmt.Sprintf("%d", opts.port))
+			} else {
+				args = append(args, "-T", "-p", fmt.Sprintf("%d", opts.port))
+			}
+		case "udp":
+			args = append(args, "-p", fmt.Sprintf("%d", opts.port))
+		default:
+			protocol = "udp"
+		}
+		if opts.sourcePort != 0 && protocol != "icmp" {
+			if flavor != FlavorLinux {
+				return "", nil, "", errors.New("sourcePort with the system binary requires Linux traceroute, enable useNative otherwise")
+			}
+			args = append(args, fmt.Sprintf("--sport=%d", opts.sourcePort))
+		}
+		if opts.source != nil {
+			args = append(args, "-s", opts.source.String())
+		}
+		if opts.sourceInterface != "" {
+			args = append(args, "-i", opts.sourceInterface)
+		}
+		if opts.tos != 0 {
+			args = append(args, "-t", fmt.Sprintf("%d", opts.tos))
+		}
+		if opts.dontFragment {
+			args = append(args, "-F")
+		}
+		if opts.paris {
+			// Linux traceroute keeps the UDP ports fixed with -U and --sport,
+			// other implementations vary them per probe
+			if flavor != FlavorLinux || protocol != "udp" {
+				return "", nil, "", errors.New("parisTraceroute with the system binary requires Linux traceroute and the udp protocol, enable useNative otherwise")
+			}
+			args = append(args, "-U", fmt.Sprintf("--sport=%d", parisSourcePort(opts.flowID)))
+		}
+		if opts.waitTime > 0 {
+			// BSD takes milliseconds, Linux takes seconds for values up to 10
+			if flavor == FlavorBSD || opts.waitTime > 10*time.Second {
+				args = append(args, "-z", fmt.Sprintf("%d", opts.waitTime.Milliseconds()))
+			} else {
+				args = append(args, "-z", strconv.FormatFloat(opts.waitTime.Seconds(), 'f', -1, 64))
+			}
+		}
+	}
+	args = append(args, opts.host)
+	if flavor != FlavorWindows {
+		// The packet length is the only positional argument after the host
+		args = append(args, fmt.Sprintf("%d", opts.packetSize))
+	}
+
+	return binary, args, protocol, nil
+}
+
+// performTracerouteExec runs the system traceroute binary and parses its output
+func (p *TraceroutePlugin) performTracerouteExec(ctx context.Context, opts traceOptions) (TracerouteResult, error) {
+	binary, flavor, err := detectTracerouteBinary()
+	if err != nil {
+		return TracerouteResult{}, err
+	}
+	binary, args, protocol, err := tracerouteCommand(binary, flavor, opts)
+	if err != nil {
+		return TracerouteResult{}, err
 	}
 
-	// Build the traceroute command
-	cmd := exec.Command("traceroute", "-n", "-m", fmt.Sprintf("%d", maxHops), host)
+	// The context kills the child process on cancellation or deadline
+	cmd := exec.CommandContext(ctx, binary, args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	// Run the command
-	err := cmd.Run()
+	commandStart := time.Now()
+	_, commandSpan := startSpan(ctx, "traceroute.command", attribute.String("process.executable.path", binary))
+	err = cmd.Run()
+	endSpan(commandSpan, err)
+	commandDuration := elapsedMs(commandStart)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return TracerouteResult{}, contextError(ctxErr)
+	}
 	if err != nil && stderr.Len() > 0 {
-		return nil, fmt.Errorf("traceroute failed: %v: %s", err, stderr.String())
+		return TracerouteResult{}, fmt.Errorf("traceroute failed: %v: %s", err, stderr.String())
 	}
 
 	output := stdout.String()
 
 	// Parse the output
-	lines := strings.Split(output, "\n")
-	hops := []map[string]interface{}{}
+	parseStart := time.Now()
+	_, parseSpan := startSpan(ctx, "traceroute.parse")
+	defer parseSpan.End()
+	hops := []HopResult{}
+	if flavor == FlavorWindows {
+		hops, err = parseTracertOutput(output)
+		if err != nil {
+			return TracerouteResult{}, err
+		}
 
-	for i, line := range lines {
-		if i == 0 || len(line) == 0 {
-			continue // Skip the header line and empty lines
+		// tracert cannot skip hops, drop the ones before firstHop instead
+		kept := hops[:0]
+		for _, hop := range hops {
+			if hop.Hop >= opts.firstHop {
+				kept = append(kept, hop)
+			}
 		}
+		hops = kept
+	} else {
+		for i, line := range strings.Split(output, "\n") {
+			if i == 0 || len(line) == 0 {
+				continue // Skip the header line and empty lines
+			}
 
-		// Extract hop information
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
-			continue
+			// Extract hop information
+			hopNumber, hopIP, samples, sent, ok := parseTracerouteLine(line)
+			if !ok {
+				continue
+			}
+
+			hop := newHop(hopNumber, hopIP, hopIP, samples, sent, protocol)
+			if needed, mtu := fragmentationAnnotation(line); needed {
+				hop.Status = HopStatusFragmentationNeeded
+				hop.NextHopMTU = mtu
+			}
+			hops = append(hops, hop)
+		}
+	}
+
+	for _, hop := range hops {
+		notifyHop(ctx, hop)
+	}
+
+	result := TracerouteResult{
+		Host:              opts.host,
+		Hops:              hops,
+		Timestamp:         time.Now().Truncate(time.Second),
+		RawOutput:         &output,
+		CommandDurationMs: commandDuration,
+		ParseDurationMs:   elapsedMs(parseStart),
+	}
+	result.ProbeSourcePort, result.ProbeDestPort = probePorts(opts, protocol)
+	return result, nil
+}
+
+// resolveSource determines the local address probes are sent from. When an
+// interface is given its first address of the target's family is used, and
+// an explicit source address must belong to that interface.
+func resolveSource(sourceAddress, sourceInterface string, ipv6 bool) (net.IP, error) {
+	var source net.IP
+	if sourceAddress != "" {
+		source = net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(sourceAddress, "["), "]"))
+		if source == nil {
+			return nil, paramError("sourceAddress", fmt.Sprintf("must be an IP address, got %q", sourceAddress))
 		}
+		if (source.To4() == nil) != ipv6 {
+			return nil, paramError("sourceAddress", fmt.Sprintf("%s does not match the address family of the target", source))
+		}
+	}
+	if sourceInterface == "" {
+		return source, nil
+	}
 
-		hopNumber, err := strconv.Atoi(parts[0])
-		if err != nil {
+	iface, err := net.InterfaceByName(sourceInterface)
+	if err != nil {
+		return nil, paramError("sourceInterface", fmt.Sprintf("%q is not usable: %v", sourceInterface, err))
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read addresses of %s: %v", sourceInterface, err)
+	}
+
+	var candidate net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || (ipNet.IP.To4() == nil) != ipv6 {
 			continue
 		}
+		if source != nil && ipNet.IP.Equal(source) {
+			return source, nil
+		}
+		if candidate == nil {
+			candidate = ipNet.IP
+		}
+	}
+
+	if source != nil {
+		return nil, fmt.Errorf("sourceAddress %s is not assigned to sourceInterface %s", source, sourceInterface)
+	}
+	if candidate == nil {
+		return nil, fmt.Errorf("sourceInterface %s has no address matching the target's address family", sourceInterface)
+	}
+	return candidate, nil
+}
 
-		var hopIP, hopName string
-		var rtt float64
+// parseTracerouteLine extracts the hop number, the first responding address
+// and every RTT sample from a line such as
+// " 6  10.0.0.1  12.1 ms *  12.5 ms", returning how many probes were sent
+func parseTracerouteLine(line string) (int, string, []float64, int, bool) {
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		return 0, "", nil, 0, false
+	}
 
-		// Get the hop IP address and RTT
-		if len(parts) >= 4 && parts[1] != "*" {
-			hopIP = parts[1]
+	hopNumber, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", nil, 0, false
+	}
 
-			// Try to get hostname
-			addr, err := net.LookupAddr(hopIP)
-			if err == nil && len(addr) > 0 {
-				hopName = strings.TrimSuffix(addr[0], ".")
-			} else {
-				hopName = hopIP
+	hopIP := "*"
+	samples := []float64{}
+	sent := 0
+	for _, part := range parts[1:] {
+		switch {
+		case part == "*":
+			sent++
+		case part == "ms" || strings.HasPrefix(part, "!"):
+			// Units and annotations such as !H or !N
+		case net.ParseIP(part) != nil:
+			if hopIP == "*" {
+				hopIP = part
 			}
+		default:
+			rtt, err := strconv.ParseFloat(strings.TrimSuffix(part, "ms"), 64)
+			if err != nil {
+				continue
+			}
+			samples = append(samples, rtt)
+			sent++
+		}
+	}
 
-			// Get RTT
-			rttStr := strings.TrimSuffix(parts[2], "ms")
-			rtt, _ = strconv.ParseFloat(rttStr, 64)
-		} else {
-			hopIP = "*"
-			hopName = "*"
-			rtt = 0
-		}
-
-		hop := map[string]interface{}{
-			"hop":  hopNumber,
-			"host": hopIP,
-			"name": hopName,
-			"rtt":  rtt,
-			"status": func() string {
-				if hopIP != "*" {
-					return "OK"
-				}
-				return "NO RESPONSE"
-			}(),
+	return hopNumber, hopIP, samples, sent, true
+}
+
+// fragmentationAnnotation reports whether a traceroute output line carries
+// the !F annotation of a probe that needed fragmenting, as in "!F-1400",
+// and the next hop MTU it gives
+func fragmentationAnnotation(line string) (bool, int) {
+	for _, part := range strings.Fields(line) {
+		if part == "!F" {
+			return true, 0
+		}
+		if mtu, ok := strings.CutPrefix(part, "!F-"); ok {
+			n, _ := strconv.Atoi(mtu)
+			return true, n
+		}
+	}
+	return false, 0
+}
+
+// newHop builds the result entry for a single hop, deriving the RTT
+// statistics and status from the collected samples
+func newHop(hopNumber int, hopIP, hopName string, samples []float64, sent int, protocol string) HopResult {
+	rttMin, rttMax, rttAvg, rttStdDev := rttStats(samples)
+
+	var rtt float64
+	if len(samples) > 0 {
+		rtt = samples[0]
+	}
+
+	// Every probe that did not come back counts as lost
+	var loss float64
+	if sent > 0 {
+		loss = float64(sent-len(samples)) / float64(sent) * 100
+	}
+
+	status := HopStatusOK
+	if len(samples) == 0 {
+		status = HopStatusNoResponse
+	} else if len(samples) < sent {
+		status = HopStatusPartial
+	}
+
+	return HopResult{
+		Hop:           hopNumber,
+		IP:            hopIP,
+		IPAddr:        net.ParseIP(hopIP),
+		Name:          hopName,
+		RTT:           rtt,
+		RTTSamples:    samples,
+		RTTMin:        rttMin,
+		RTTMax:        rttMax,
+		RTTAvg:        rttAvg,
+		RTTStdDev:     rttStdDev,
+		Jitter:        jitter(samples),
+		ProbesSent:    sent,
+		Loss:          loss,
+		ProbeProtocol: protocol,
+		Status:        status,
+	}
+}
+
+// rttStats returns the minimum, maximum, mean and population standard
+// deviation of the RTT samples
+func rttStats(samples []float64) (float64, float64, float64, float64) {
+	if len(samples) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	min, max, sum := samples[0], samples[0], 0.0
+	for _, s := range samples {
+		if s < min {
+			min = s
 		}
+		if s > max {
+			max = s
+		}
+		sum += s
+	}
+	avg := sum / float64(len(samples))
+
+	var variance float64
+	for _, s := range samples {
+		variance += (s - avg) * (s - avg)
+	}
+	variance /= float64(len(samples))
 
-		hops = append(hops, hop)
+	return min, max, avg, math.Sqrt(variance)
+}
+
+// jitter returns the mean absolute difference between successive RTT
+// values, or 0 when there are fewer than two
+func jitter(rtts []float64) float64 {
+	if len(rtts) < 2 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i < len(rtts); i++ {
+		sum += math.Abs(rtts[i] - rtts[i-1])
+	}
+	return sum / float64(len(rtts)-1)
+}
+
+// resolveTarget resolves the host to the address that will be traced,
+// preferring IPv4 like the traceroute binary does. resolver, when not nil,
+// is asked first and the system resolver when it fails.
+func resolveTarget(ctx context.Context, host string, resolver *net.Resolver) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
 	}
 
-	return map[string]interface{}{
-		"host":      host,
-		"hops":      hops,
-		"timestamp": time.Now().Format(time.RFC3339),
-		"rawOutput": output,
-	}, nil
+	var addrs []net.IPAddr
+	var err error
+	if resolver != nil {
+		addrs, err = resolver.LookupIPAddr(ctx, host)
+	}
+	if resolver == nil || err != nil && ctx.Err() == nil {
+		addrs, err = net.DefaultResolver.LookupIPAddr(ctx, host)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, contextError(ctxErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve host %s: %v", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for host %s", host)
+	}
+
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP, nil
+		}
+	}
+	return addrs[0].IP, nil
+}
+
+// isDestination reports whether hopIP is the target or any other address the
+// host name resolves to, so hosts with several addresses are recognized
+func isDestination(ctx context.Context, host string, target net.IP, hopIP string) bool {
+	ip := net.ParseIP(hopIP)
+	if ip == nil {
+		return false
+	}
+	if ip.Equal(target) {
+		return true
+	}
+	if net.ParseIP(host) != nil {
+		return false
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if addr.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// contextError wraps a context error so callers can tell a timeout or
+// cancellation apart from a failed trace using errors.Is
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("traceroute timed out: %w", err)
+	}
+	return fmt.Errorf("traceroute cancelled: %w", err)
+}
+
+// cliFlag looks up an optional "--name=value" or "--name" argument given
+// after the command
+func cliFlag(name string) (string, bool) {
+	for _, arg := range os.Args[2:] {
+		if arg == "--"+name {
+			return "", true
+		}
+		if strings.HasPrefix(arg, "--"+name+"=") {
+			return strings.TrimPrefix(arg, "--"+name+"="), true
+		}
+	}
+	return "", false
+}
+
+// readStdinParams reads the single JSON object of --stdin
+func readStdinParams(r io.Reader) (map[string]interface{}, error) {
+	dec := json.NewDecoder(r)
+	var params map[string]interface{}
+	if err := dec.Decode(&params); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("no parameters on stdin")
+		}
+		return nil, fmt.Errorf("invalid parameters on stdin: %v", err)
+	}
+	if params == nil {
+		return nil, fmt.Errorf("invalid parameters on stdin: expected a JSON object")
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid parameters on stdin: unexpected data after the JSON object")
+	}
+	return params, nil
 }
 
 // Main function
 func main() {
-	// Create plugin instance
-	plugin := NewPlugin()
+	// Create plugin instance, using the defaults declared in plugin.json
+	// when it is present
+	plugin, err := NewPluginFromDefinition(definitionPath)
+	if errors.Is(err, os.ErrNotExist) {
+		plugin = NewPlugin()
+	} else if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	// Check command line arguments
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: plugin.go --definition|--execute='{\"params\":...}'")
+		fmt.Println("Usage: plugin.go --definition|--definition-schema|--version|--list-interfaces|--stats|--grpc=addr|--http=addr|--socket=path|--validate='{...}'|--batch-file=file [--parallelism=n] [--params='{...}']|--execute='{\"params\":...}'|--stdin|--multiplex [--output=json|ndjson|csv|xml|table|influx|dot|mermaid|proto|proto-json|msgpack] [--pretty] [--watch=seconds] [--dry-run] [--serve=addr [--interval=seconds]] [--config=file.json|file.yaml]")
 		os.Exit(1)
 	}
 
+	// Handle --config, which sets parameter defaults for any command
+	if path, ok := cliFlag("config"); ok {
+		defaults, err := loadConfigFile(path)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		plugin.Config.Defaults = defaults
+	}
+
 	// Handle --definition argument
 	if os.Args[1] == "--definition" {
 		// Read plugin.json for definition
-		definitionBytes, err := os.ReadFile("plugin.json")
+		definitionBytes, err := os.ReadFile(definitionPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		var definition map[string]interface{}
+		if err := json.Unmarshal(definitionBytes, &definition); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		definition["pluginVersion"] = pluginVersion
+		definition["schemaVersion"] = schemaVersion
+		definitionBytes, err = json.MarshalIndent(definition, "", "  ")
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -245,27 +1422,283 @@ func main() {
 		return
 	}
 
-	// Handle --execute argument
-	if strings.HasPrefix(os.Args[1], "--execute=") {
-		// Extract parameters JSON
-		paramsJSON := strings.TrimPrefix(os.Args[1], "--execute=")
+	// Handle --definition-schema argument
+	if os.Args[1] == "--definition-schema" {
+		schema, err := paramsSchema(definitionPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		schemaBytes, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(string(schemaBytes))
+		return
+	}
+
+	// Handle --version argument
+	if os.Args[1] == "--version" {
+		fmt.Println(pluginVersion)
+		return
+	}
+
+	// Handle --list-interfaces argument
+	if os.Args[1] == "--list-interfaces" {
+		interfaces, err := listInterfaces()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		output, err := json.MarshalIndent(interfaces, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	// Handle --stats argument
+	if os.Args[1] == "--stats" {
+		stats := plugin.GetStatistics()
+		output, _ := cliFlag("output")
+		_, pretty := cliFlag("pretty")
+		var err error
+		switch output {
+		case "proto":
+			var msg []byte
+			if msg, err = stats.MarshalProto(); err == nil {
+				_, err = os.Stdout.Write(msg)
+			}
+		case "proto-json":
+			err = writeProtoJSONResult(os.Stdout, statsToProto(stats), pretty)
+		default:
+			var statsJSON []byte
+			statsJSON, err = json.Marshal(stats)
+			if err == nil {
+				fmt.Println(string(statsJSON))
+			}
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle --grpc argument
+	if strings.HasPrefix(os.Args[1], "--grpc=") {
+		if err := runGRPC(plugin, strings.TrimPrefix(os.Args[1], "--grpc=")); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle --http argument
+	if strings.HasPrefix(os.Args[1], "--http=") {
+		if err := runHTTP(plugin, strings.TrimPrefix(os.Args[1], "--http=")); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle --multiplex argument, answering requests until stdin is closed
+	if os.Args[1] == "--multiplex" {
+		if err := runMultiplex(plugin, os.Stdin, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle --socket argument
+	if strings.HasPrefix(os.Args[1], "--socket=") {
+		if err := runSocket(plugin, strings.TrimPrefix(os.Args[1], "--socket=")); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle --validate argument, exiting with 2 if the parameters are invalid
+	if strings.HasPrefix(os.Args[1], "--validate=") {
+		var params map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(os.Args[1], "--validate=")), &params); err != nil {
+			errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+			fmt.Println(string(errorJSON))
+			os.Exit(2)
+		}
+		normalized, err := plugin.normalizeParams(params)
+		if err != nil {
+			errorJSON, _ := json.Marshal(errorReport(err))
+			fmt.Println(string(errorJSON))
+			os.Exit(2)
+		}
+		normalizedJSON, err := json.Marshal(normalized)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(string(normalizedJSON))
+		return
+	}
 
+	// Handle --batch-file argument, tracing every target of the file
+	if strings.HasPrefix(os.Args[1], "--batch-file=") {
+		common := map[string]interface{}{}
+		if paramsJSON, ok := cliFlag("params"); ok {
+			if err := json.Unmarshal([]byte(paramsJSON), &common); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		parallelism := defaultBatchParallelism
+		if value, ok := cliFlag("parallelism"); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				fmt.Printf("invalid --parallelism %q\n", value)
+				os.Exit(1)
+			}
+			parallelism = n
+		}
+		batch, err := runBatch(plugin, strings.TrimPrefix(os.Args[1], "--batch-file="), common, parallelism)
+		if err != nil {
+			errorJSON, _ := json.Marshal(errorReport(err))
+			fmt.Println(string(errorJSON))
+			os.Exit(exitCode(err))
+		}
+		marshal := json.Marshal
+		if _, pretty := cliFlag("pretty"); pretty {
+			marshal = func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+		}
+		batchJSON, err := marshal(batch)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(string(batchJSON))
+		return
+	}
+
+	// Handle --execute argument, or --stdin passing the same parameters on
+	// standard input
+	if strings.HasPrefix(os.Args[1], "--execute=") || os.Args[1] == "--stdin" {
 		// Parse parameters
 		var params map[string]interface{}
-		if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+		var err error
+		if os.Args[1] == "--stdin" {
+			params, err = readStdinParams(os.Stdin)
+		} else {
+			err = json.Unmarshal([]byte(strings.TrimPrefix(os.Args[1], "--execute=")), &params)
+		}
+		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 
+		// Output results in the requested format, JSON by default
+		output, ok := cliFlag("output")
+		if !ok {
+			output, _ = params["outputFormat"].(string)
+		}
+		_, pretty := cliFlag("pretty")
+		if !pretty {
+			in := paramReader{params: params}
+			pretty, _ = in.bool("prettyPrint")
+			if in.err != nil {
+				errorJSON, _ := json.Marshal(errorReport(in.err))
+				fmt.Println(string(errorJSON))
+				os.Exit(exitCode(in.err))
+			}
+		}
+
+		// Handle --dry-run, printing what would be run instead of tracing
+		if _, ok := cliFlag("dry-run"); ok {
+			params["dryRun"] = true
+			delete(params, "continueToIterate")
+			result, err := plugin.Execute(context.Background(), params)
+			if err != nil {
+				errorJSON, _ := json.Marshal(errorReport(err))
+				fmt.Println(string(errorJSON))
+				os.Exit(exitCode(err))
+			}
+			fmt.Println(result.(TracerouteResult).DryRun)
+			return
+		}
+
+		// Handle --watch, tracing repeatedly until interrupted
+		if watch, ok := cliFlag("watch"); ok {
+			interval := defaultWatchInterval
+			if watch != "" {
+				seconds, err := strconv.ParseFloat(watch, 64)
+				if err != nil || seconds <= 0 {
+					fmt.Printf("invalid --watch interval %q\n", watch)
+					os.Exit(1)
+				}
+				interval = time.Duration(seconds * float64(time.Second))
+			}
+			// Binary messages have no framing to tell iterations apart
+			if output == "proto" {
+				fmt.Println("--output=proto can not be used with --watch, use proto-json or ndjson")
+				os.Exit(1)
+			}
+			if err := runWatch(plugin, params, interval, output, pretty); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// Handle --serve, exposing Prometheus metrics of repeated traces
+		if addr, ok := cliFlag("serve"); ok {
+			interval := defaultServeInterval
+			if value, ok := cliFlag("interval"); ok {
+				seconds, err := strconv.ParseFloat(value, 64)
+				if err != nil || seconds <= 0 {
+					fmt.Printf("invalid --interval %q\n", value)
+					os.Exit(1)
+				}
+				interval = time.Duration(seconds * float64(time.Second))
+			}
+			if err := runServe(plugin, params, addr, interval); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		// Execute plugin
-		result, err := plugin.Execute(params)
+		result, err := plugin.Execute(context.Background(), params)
 		if err != nil {
-			fmt.Printf("{\"error\": \"%s\"}\n", err.Error())
-			os.Exit(1)
+			// Messages may quote parameter values, encode them properly
+			errorJSON, _ := json.Marshal(errorReport(err))
+			fmt.Println(string(errorJSON))
+			os.Exit(exitCode(err))
 		}
 
-		// Output result as JSON
-		resultJSON, err := json.Marshal(result)
+		if traceResult, ok := result.(TracerouteResult); ok {
+			// A graph of an iteration overlays the restored history
+			if output == "dot" && traceResult.IterationCount > 0 {
+				err = ExportDOT(os.Stdout, plugin.Results...)
+			} else {
+				err = writeResult(os.Stdout, traceResult, output, pretty)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		marshal := json.Marshal
+		if pretty {
+			marshal = func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+		}
+		resultJSON, err := marshal(result)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
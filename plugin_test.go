@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTracerouteLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		hop     int
+		ip      string
+		samples []float64
+		sent    int
+		ok      bool
+	}{
+		{"all answered", " 1  192.168.1.1  0.512 ms  0.431 ms  0.398 ms", 1, "192.168.1.1", []float64{0.512, 0.431, 0.398}, 3, true},
+		{"no answer", " 2  * * *", 2, "*", []float64{}, 3, true},
+		{"partial", " 3  10.0.0.1  12.1 ms *  12.5 ms", 3, "10.0.0.1", []float64{12.1, 12.5}, 3, true},
+		{"late first answer", " 4  *  10.0.0.2  5.0 ms  6.0 ms", 4, "10.0.0.2", []float64{5, 6}, 3, true},
+		{"several addresses", " 5  10.0.0.3  5.000 ms 10.0.0.4  6.000 ms  7.000 ms", 5, "10.0.0.3", []float64{5, 6, 7}, 3, true},
+		{"annotations", " 6  203.0.113.1  20.1 ms !H  *  21.0 ms !N", 6, "203.0.113.1", []float64{20.1, 21}, 3, true},
+		{"unit without space", "10  192.0.2.1  1.5ms  *  *", 10, "192.0.2.1", []float64{1.5}, 3, true},
+		{"ipv6", " 7  2001:db8::1  1.1 ms  1.2 ms  1.3 ms", 7, "2001:db8::1", []float64{1.1, 1.2, 1.3}, 3, true},
+		{"header", "traceroute to example.com (93.184.216.34), 30 hops max, 60 byte packets", 0, "", nil, 0, false},
+		{"hop number only", " 8", 0, "", nil, 0, false},
+		{"empty", "", 0, "", nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hop, ip, samples, sent, ok := parseTracerouteLine(tt.line)
+			if hop != tt.hop || ip != tt.ip || sent != tt.sent || ok != tt.ok {
+				t.Errorf("got hop %d, ip %q, %d probes, ok %v; want hop %d, ip %q, %d probes, ok %v",
+					hop, ip, sent, ok, tt.hop, tt.ip, tt.sent, tt.ok)
+			}
+			if !reflect.DeepEqual(samples, tt.samples) {
+				t.Errorf("got samples %v, want %v", samples, tt.samples)
+			}
+		})
+	}
+}
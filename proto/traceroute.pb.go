@@ -0,0 +1,1482 @@
+// TracerouteService is served by the plugin when started with
+// --grpc=<address>. The server speaks gRPC over HTTP/2 without TLS.
+//
+// The Go package traceroutepb is generated from this file, run go generate
+// in the module root after changing it. Client stubs for other languages can
+// be generated with protoc as well. grpc_test.go calls the service with a
+// grpc-go client using this file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: traceroute.proto
+
+package traceroutepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HopStatus int32
+
+const (
+	HopStatus_HOP_STATUS_OK          HopStatus = 0
+	HopStatus_HOP_STATUS_NO_RESPONSE HopStatus = 1
+	HopStatus_HOP_STATUS_PARTIAL     HopStatus = 2
+	// The hop repeats the address of an earlier hop.
+	HopStatus_HOP_STATUS_LOOP HopStatus = 3
+	// The hop could not forward a probe that must not be fragmented.
+	HopStatus_HOP_STATUS_FRAGMENTATION_NEEDED HopStatus = 4
+	// The hop reported that the target can not be reached.
+	HopStatus_HOP_STATUS_UNREACHABLE HopStatus = 5
+)
+
+// Enum value maps for HopStatus.
+var (
+	HopStatus_name = map[int32]string{
+		0: "HOP_STATUS_OK",
+		1: "HOP_STATUS_NO_RESPONSE",
+		2: "HOP_STATUS_PARTIAL",
+		3: "HOP_STATUS_LOOP",
+		4: "HOP_STATUS_FRAGMENTATION_NEEDED",
+		5: "HOP_STATUS_UNREACHABLE",
+	}
+	HopStatus_value = map[string]int32{
+		"HOP_STATUS_OK":                   0,
+		"HOP_STATUS_NO_RESPONSE":          1,
+		"HOP_STATUS_PARTIAL":              2,
+		"HOP_STATUS_LOOP":                 3,
+		"HOP_STATUS_FRAGMENTATION_NEEDED": 4,
+		"HOP_STATUS_UNREACHABLE":          5,
+	}
+)
+
+func (x HopStatus) Enum() *HopStatus {
+	p := new(HopStatus)
+	*p = x
+	return p
+}
+
+func (x HopStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HopStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_traceroute_proto_enumTypes[0].Descriptor()
+}
+
+func (HopStatus) Type() protoreflect.EnumType {
+	return &file_traceroute_proto_enumTypes[0]
+}
+
+func (x HopStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HopStatus.Descriptor instead.
+func (HopStatus) EnumDescriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{0}
+}
+
+type TracerouteRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Host       string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	MaxHops    int32                  `protobuf:"varint,2,opt,name=max_hops,json=maxHops,proto3" json:"max_hops,omitempty"`
+	ProbeCount int32                  `protobuf:"varint,3,opt,name=probe_count,json=probeCount,proto3" json:"probe_count,omitempty"`
+	// One of "icmp", "udp" or "tcp".
+	Protocol   string `protobuf:"bytes,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Port       int32  `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`
+	UseNative  bool   `protobuf:"varint,6,opt,name=use_native,json=useNative,proto3" json:"use_native,omitempty"`
+	ResolveDns *bool  `protobuf:"varint,7,opt,name=resolve_dns,json=resolveDns,proto3,oneof" json:"resolve_dns,omitempty"`
+	// Overall timeout in seconds.
+	OverallTimeout float64 `protobuf:"fixed64,8,opt,name=overall_timeout,json=overallTimeout,proto3" json:"overall_timeout,omitempty"`
+	FirstHop       int32   `protobuf:"varint,9,opt,name=first_hop,json=firstHop,proto3" json:"first_hop,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TracerouteRequest) Reset() {
+	*x = TracerouteRequest{}
+	mi := &file_traceroute_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TracerouteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TracerouteRequest) ProtoMessage() {}
+
+func (x *TracerouteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TracerouteRequest.ProtoReflect.Descriptor instead.
+func (*TracerouteRequest) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TracerouteRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *TracerouteRequest) GetMaxHops() int32 {
+	if x != nil {
+		return x.MaxHops
+	}
+	return 0
+}
+
+func (x *TracerouteRequest) GetProbeCount() int32 {
+	if x != nil {
+		return x.ProbeCount
+	}
+	return 0
+}
+
+func (x *TracerouteRequest) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *TracerouteRequest) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *TracerouteRequest) GetUseNative() bool {
+	if x != nil {
+		return x.UseNative
+	}
+	return false
+}
+
+func (x *TracerouteRequest) GetResolveDns() bool {
+	if x != nil && x.ResolveDns != nil {
+		return *x.ResolveDns
+	}
+	return false
+}
+
+func (x *TracerouteRequest) GetOverallTimeout() float64 {
+	if x != nil {
+		return x.OverallTimeout
+	}
+	return 0
+}
+
+func (x *TracerouteRequest) GetFirstHop() int32 {
+	if x != nil {
+		return x.FirstHop
+	}
+	return 0
+}
+
+type Hop struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Number int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	// "*" when the hop did not respond.
+	Ip            string    `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	Name          string    `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	RttMs         float64   `protobuf:"fixed64,4,opt,name=rtt_ms,json=rttMs,proto3" json:"rtt_ms,omitempty"`
+	LossPercent   float64   `protobuf:"fixed64,5,opt,name=loss_percent,json=lossPercent,proto3" json:"loss_percent,omitempty"`
+	Status        string    `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Asn           int32     `protobuf:"varint,7,opt,name=asn,proto3" json:"asn,omitempty"`
+	Country       string    `protobuf:"bytes,8,opt,name=country,proto3" json:"country,omitempty"`
+	RttSamples    []float64 `protobuf:"fixed64,9,rep,packed,name=rtt_samples,json=rttSamples,proto3" json:"rtt_samples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hop) Reset() {
+	*x = Hop{}
+	mi := &file_traceroute_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hop) ProtoMessage() {}
+
+func (x *Hop) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hop.ProtoReflect.Descriptor instead.
+func (*Hop) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hop) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Hop) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Hop) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Hop) GetRttMs() float64 {
+	if x != nil {
+		return x.RttMs
+	}
+	return 0
+}
+
+func (x *Hop) GetLossPercent() float64 {
+	if x != nil {
+		return x.LossPercent
+	}
+	return 0
+}
+
+func (x *Hop) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Hop) GetAsn() int32 {
+	if x != nil {
+		return x.Asn
+	}
+	return 0
+}
+
+func (x *Hop) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Hop) GetRttSamples() []float64 {
+	if x != nil {
+		return x.RttSamples
+	}
+	return nil
+}
+
+type TracerouteResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Host               string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Hops               []*Hop                 `protobuf:"bytes,2,rep,name=hops,proto3" json:"hops,omitempty"`
+	AddressFamily      string                 `protobuf:"bytes,3,opt,name=address_family,json=addressFamily,proto3" json:"address_family,omitempty"`
+	DestinationReached bool                   `protobuf:"varint,4,opt,name=destination_reached,json=destinationReached,proto3" json:"destination_reached,omitempty"`
+	ReachedAtHop       int32                  `protobuf:"varint,5,opt,name=reached_at_hop,json=reachedAtHop,proto3" json:"reached_at_hop,omitempty"`
+	TimestampUnix      int64                  `protobuf:"varint,6,opt,name=timestamp_unix,json=timestampUnix,proto3" json:"timestamp_unix,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *TracerouteResponse) Reset() {
+	*x = TracerouteResponse{}
+	mi := &file_traceroute_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TracerouteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TracerouteResponse) ProtoMessage() {}
+
+func (x *TracerouteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TracerouteResponse.ProtoReflect.Descriptor instead.
+func (*TracerouteResponse) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TracerouteResponse) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *TracerouteResponse) GetHops() []*Hop {
+	if x != nil {
+		return x.Hops
+	}
+	return nil
+}
+
+func (x *TracerouteResponse) GetAddressFamily() string {
+	if x != nil {
+		return x.AddressFamily
+	}
+	return ""
+}
+
+func (x *TracerouteResponse) GetDestinationReached() bool {
+	if x != nil {
+		return x.DestinationReached
+	}
+	return false
+}
+
+func (x *TracerouteResponse) GetReachedAtHop() int32 {
+	if x != nil {
+		return x.ReachedAtHop
+	}
+	return 0
+}
+
+func (x *TracerouteResponse) GetTimestampUnix() int64 {
+	if x != nil {
+		return x.TimestampUnix
+	}
+	return 0
+}
+
+type HopEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Hop           *Hop                   `protobuf:"bytes,2,opt,name=hop,proto3" json:"hop,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HopEvent) Reset() {
+	*x = HopEvent{}
+	mi := &file_traceroute_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HopEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HopEvent) ProtoMessage() {}
+
+func (x *HopEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HopEvent.ProtoReflect.Descriptor instead.
+func (*HopEvent) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HopEvent) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *HopEvent) GetHop() *Hop {
+	if x != nil {
+		return x.Hop
+	}
+	return nil
+}
+
+type MplsLabel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         uint32                 `protobuf:"varint,1,opt,name=label,proto3" json:"label,omitempty"`
+	Exp           uint32                 `protobuf:"varint,2,opt,name=exp,proto3" json:"exp,omitempty"`
+	Stack         bool                   `protobuf:"varint,3,opt,name=stack,proto3" json:"stack,omitempty"`
+	Ttl           uint32                 `protobuf:"varint,4,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MplsLabel) Reset() {
+	*x = MplsLabel{}
+	mi := &file_traceroute_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MplsLabel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MplsLabel) ProtoMessage() {}
+
+func (x *MplsLabel) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MplsLabel.ProtoReflect.Descriptor instead.
+func (*MplsLabel) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MplsLabel) GetLabel() uint32 {
+	if x != nil {
+		return x.Label
+	}
+	return 0
+}
+
+func (x *MplsLabel) GetExp() uint32 {
+	if x != nil {
+		return x.Exp
+	}
+	return 0
+}
+
+func (x *MplsLabel) GetStack() bool {
+	if x != nil {
+		return x.Stack
+	}
+	return false
+}
+
+func (x *MplsLabel) GetTtl() uint32 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+type HopResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Hop   int32                  `protobuf:"varint,1,opt,name=hop,proto3" json:"hop,omitempty"`
+	// "*" when the hop did not respond.
+	Ip            string       `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	Name          string       `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Rtt           float64      `protobuf:"fixed64,4,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	RttSamples    []float64    `protobuf:"fixed64,5,rep,packed,name=rtt_samples,json=rttSamples,proto3" json:"rtt_samples,omitempty"`
+	RttMin        float64      `protobuf:"fixed64,6,opt,name=rtt_min,json=rttMin,proto3" json:"rtt_min,omitempty"`
+	RttMax        float64      `protobuf:"fixed64,7,opt,name=rtt_max,json=rttMax,proto3" json:"rtt_max,omitempty"`
+	RttAvg        float64      `protobuf:"fixed64,8,opt,name=rtt_avg,json=rttAvg,proto3" json:"rtt_avg,omitempty"`
+	RttStdDev     float64      `protobuf:"fixed64,9,opt,name=rtt_std_dev,json=rttStdDev,proto3" json:"rtt_std_dev,omitempty"`
+	Jitter        float64      `protobuf:"fixed64,10,opt,name=jitter,proto3" json:"jitter,omitempty"`
+	ProbesSent    int32        `protobuf:"varint,11,opt,name=probes_sent,json=probesSent,proto3" json:"probes_sent,omitempty"`
+	Loss          float64      `protobuf:"fixed64,12,opt,name=loss,proto3" json:"loss,omitempty"`
+	ProbeProtocol string       `protobuf:"bytes,13,opt,name=probe_protocol,json=probeProtocol,proto3" json:"probe_protocol,omitempty"`
+	Status        HopStatus    `protobuf:"varint,14,opt,name=status,proto3,enum=netscout.traceroute.v1.HopStatus" json:"status,omitempty"`
+	Mtu           int32        `protobuf:"varint,15,opt,name=mtu,proto3" json:"mtu,omitempty"`
+	NextHopMtu    int32        `protobuf:"varint,16,opt,name=next_hop_mtu,json=nextHopMtu,proto3" json:"next_hop_mtu,omitempty"`
+	Asn           int32        `protobuf:"varint,17,opt,name=asn,proto3" json:"asn,omitempty"`
+	AsnOrg        string       `protobuf:"bytes,18,opt,name=asn_org,json=asnOrg,proto3" json:"asn_org,omitempty"`
+	Country       string       `protobuf:"bytes,19,opt,name=country,proto3" json:"country,omitempty"`
+	City          string       `protobuf:"bytes,20,opt,name=city,proto3" json:"city,omitempty"`
+	Latitude      float64      `protobuf:"fixed64,21,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64      `protobuf:"fixed64,22,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Retries       int32        `protobuf:"varint,23,opt,name=retries,proto3" json:"retries,omitempty"`
+	MplsLabels    []*MplsLabel `protobuf:"bytes,24,rep,name=mpls_labels,json=mplsLabels,proto3" json:"mpls_labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HopResult) Reset() {
+	*x = HopResult{}
+	mi := &file_traceroute_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HopResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HopResult) ProtoMessage() {}
+
+func (x *HopResult) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HopResult.ProtoReflect.Descriptor instead.
+func (*HopResult) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *HopResult) GetHop() int32 {
+	if x != nil {
+		return x.Hop
+	}
+	return 0
+}
+
+func (x *HopResult) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *HopResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HopResult) GetRtt() float64 {
+	if x != nil {
+		return x.Rtt
+	}
+	return 0
+}
+
+func (x *HopResult) GetRttSamples() []float64 {
+	if x != nil {
+		return x.RttSamples
+	}
+	return nil
+}
+
+func (x *HopResult) GetRttMin() float64 {
+	if x != nil {
+		return x.RttMin
+	}
+	return 0
+}
+
+func (x *HopResult) GetRttMax() float64 {
+	if x != nil {
+		return x.RttMax
+	}
+	return 0
+}
+
+func (x *HopResult) GetRttAvg() float64 {
+	if x != nil {
+		return x.RttAvg
+	}
+	return 0
+}
+
+func (x *HopResult) GetRttStdDev() float64 {
+	if x != nil {
+		return x.RttStdDev
+	}
+	return 0
+}
+
+func (x *HopResult) GetJitter() float64 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
+func (x *HopResult) GetProbesSent() int32 {
+	if x != nil {
+		return x.ProbesSent
+	}
+	return 0
+}
+
+func (x *HopResult) GetLoss() float64 {
+	if x != nil {
+		return x.Loss
+	}
+	return 0
+}
+
+func (x *HopResult) GetProbeProtocol() string {
+	if x != nil {
+		return x.ProbeProtocol
+	}
+	return ""
+}
+
+func (x *HopResult) GetStatus() HopStatus {
+	if x != nil {
+		return x.Status
+	}
+	return HopStatus_HOP_STATUS_OK
+}
+
+func (x *HopResult) GetMtu() int32 {
+	if x != nil {
+		return x.Mtu
+	}
+	return 0
+}
+
+func (x *HopResult) GetNextHopMtu() int32 {
+	if x != nil {
+		return x.NextHopMtu
+	}
+	return 0
+}
+
+func (x *HopResult) GetAsn() int32 {
+	if x != nil {
+		return x.Asn
+	}
+	return 0
+}
+
+func (x *HopResult) GetAsnOrg() string {
+	if x != nil {
+		return x.AsnOrg
+	}
+	return ""
+}
+
+func (x *HopResult) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *HopResult) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *HopResult) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *HopResult) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *HopResult) GetRetries() int32 {
+	if x != nil {
+		return x.Retries
+	}
+	return 0
+}
+
+func (x *HopResult) GetMplsLabels() []*MplsLabel {
+	if x != nil {
+		return x.MplsLabels
+	}
+	return nil
+}
+
+type TracerouteResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Host            string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	NormalizedHost  string                 `protobuf:"bytes,2,opt,name=normalized_host,json=normalizedHost,proto3" json:"normalized_host,omitempty"`
+	ResolvedHost    string                 `protobuf:"bytes,3,opt,name=resolved_host,json=resolvedHost,proto3" json:"resolved_host,omitempty"`
+	ExecutionId     string                 `protobuf:"bytes,4,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	CorrelationId   string                 `protobuf:"bytes,5,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	PluginVersion   string                 `protobuf:"bytes,6,opt,name=plugin_version,json=pluginVersion,proto3" json:"plugin_version,omitempty"`
+	SchemaVersion   string                 `protobuf:"bytes,7,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	Hops            []*HopResult           `protobuf:"bytes,8,rep,name=hops,proto3" json:"hops,omitempty"`
+	AddressFamily   string                 `protobuf:"bytes,9,opt,name=address_family,json=addressFamily,proto3" json:"address_family,omitempty"`
+	SourceAddress   string                 `protobuf:"bytes,10,opt,name=source_address,json=sourceAddress,proto3" json:"source_address,omitempty"`
+	PacketSize      int32                  `protobuf:"varint,11,opt,name=packet_size,json=packetSize,proto3" json:"packet_size,omitempty"`
+	TosUsed         int32                  `protobuf:"varint,12,opt,name=tos_used,json=tosUsed,proto3" json:"tos_used,omitempty"`
+	FlowId          int32                  `protobuf:"varint,13,opt,name=flow_id,json=flowId,proto3" json:"flow_id,omitempty"`
+	PathMtu         int32                  `protobuf:"varint,14,opt,name=path_mtu,json=pathMtu,proto3" json:"path_mtu,omitempty"`
+	ProbeSourcePort int32                  `protobuf:"varint,15,opt,name=probe_source_port,json=probeSourcePort,proto3" json:"probe_source_port,omitempty"`
+	ProbeDestPort   int32                  `protobuf:"varint,16,opt,name=probe_dest_port,json=probeDestPort,proto3" json:"probe_dest_port,omitempty"`
+	// Unset when the result has no timestamp.
+	TimestampUnixNano  int64    `protobuf:"varint,17,opt,name=timestamp_unix_nano,json=timestampUnixNano,proto3" json:"timestamp_unix_nano,omitempty"`
+	DestinationReached bool     `protobuf:"varint,18,opt,name=destination_reached,json=destinationReached,proto3" json:"destination_reached,omitempty"`
+	ReachedAtHop       int32    `protobuf:"varint,19,opt,name=reached_at_hop,json=reachedAtHop,proto3" json:"reached_at_hop,omitempty"`
+	Truncated          bool     `protobuf:"varint,20,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	HasMpls            bool     `protobuf:"varint,21,opt,name=has_mpls,json=hasMpls,proto3" json:"has_mpls,omitempty"`
+	HasLoop            bool     `protobuf:"varint,22,opt,name=has_loop,json=hasLoop,proto3" json:"has_loop,omitempty"`
+	Warnings           []string `protobuf:"bytes,23,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	HasAlerts          bool     `protobuf:"varint,24,opt,name=has_alerts,json=hasAlerts,proto3" json:"has_alerts,omitempty"`
+	SlaBreached        bool     `protobuf:"varint,25,opt,name=sla_breached,json=slaBreached,proto3" json:"sla_breached,omitempty"`
+	CommandDurationMs  float64  `protobuf:"fixed64,26,opt,name=command_duration_ms,json=commandDurationMs,proto3" json:"command_duration_ms,omitempty"`
+	DnsDurationMs      float64  `protobuf:"fixed64,27,opt,name=dns_duration_ms,json=dnsDurationMs,proto3" json:"dns_duration_ms,omitempty"`
+	ParseDurationMs    float64  `protobuf:"fixed64,28,opt,name=parse_duration_ms,json=parseDurationMs,proto3" json:"parse_duration_ms,omitempty"`
+	IterationCount     int32    `protobuf:"varint,29,opt,name=iteration_count,json=iterationCount,proto3" json:"iteration_count,omitempty"`
+	ElapsedTimeNanos   int64    `protobuf:"varint,30,opt,name=elapsed_time_nanos,json=elapsedTimeNanos,proto3" json:"elapsed_time_nanos,omitempty"`
+	PathChanged        bool     `protobuf:"varint,31,opt,name=path_changed,json=pathChanged,proto3" json:"path_changed,omitempty"`
+	ChangedHops        []int32  `protobuf:"varint,32,rep,packed,name=changed_hops,json=changedHops,proto3" json:"changed_hops,omitempty"`
+	Converged          bool     `protobuf:"varint,33,opt,name=converged,proto3" json:"converged,omitempty"`
+	Fingerprint        string   `protobuf:"bytes,34,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	// The paths found by discoverAllPaths.
+	Paths             []*TracerouteResult `protobuf:"bytes,35,rep,name=paths,proto3" json:"paths,omitempty"`
+	DnsServer         string              `protobuf:"bytes,36,opt,name=dns_server,json=dnsServer,proto3" json:"dns_server,omitempty"`
+	SelectedInterface string              `protobuf:"bytes,37,opt,name=selected_interface,json=selectedInterface,proto3" json:"selected_interface,omitempty"`
+	SelectedSourceIp  string              `protobuf:"bytes,38,opt,name=selected_source_ip,json=selectedSourceIp,proto3" json:"selected_source_ip,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *TracerouteResult) Reset() {
+	*x = TracerouteResult{}
+	mi := &file_traceroute_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TracerouteResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TracerouteResult) ProtoMessage() {}
+
+func (x *TracerouteResult) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TracerouteResult.ProtoReflect.Descriptor instead.
+func (*TracerouteResult) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TracerouteResult) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetNormalizedHost() string {
+	if x != nil {
+		return x.NormalizedHost
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetResolvedHost() string {
+	if x != nil {
+		return x.ResolvedHost
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetPluginVersion() string {
+	if x != nil {
+		return x.PluginVersion
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetSchemaVersion() string {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetHops() []*HopResult {
+	if x != nil {
+		return x.Hops
+	}
+	return nil
+}
+
+func (x *TracerouteResult) GetAddressFamily() string {
+	if x != nil {
+		return x.AddressFamily
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetSourceAddress() string {
+	if x != nil {
+		return x.SourceAddress
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetPacketSize() int32 {
+	if x != nil {
+		return x.PacketSize
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetTosUsed() int32 {
+	if x != nil {
+		return x.TosUsed
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetFlowId() int32 {
+	if x != nil {
+		return x.FlowId
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetPathMtu() int32 {
+	if x != nil {
+		return x.PathMtu
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetProbeSourcePort() int32 {
+	if x != nil {
+		return x.ProbeSourcePort
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetProbeDestPort() int32 {
+	if x != nil {
+		return x.ProbeDestPort
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetTimestampUnixNano() int64 {
+	if x != nil {
+		return x.TimestampUnixNano
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetDestinationReached() bool {
+	if x != nil {
+		return x.DestinationReached
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetReachedAtHop() int32 {
+	if x != nil {
+		return x.ReachedAtHop
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetHasMpls() bool {
+	if x != nil {
+		return x.HasMpls
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetHasLoop() bool {
+	if x != nil {
+		return x.HasLoop
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *TracerouteResult) GetHasAlerts() bool {
+	if x != nil {
+		return x.HasAlerts
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetSlaBreached() bool {
+	if x != nil {
+		return x.SlaBreached
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetCommandDurationMs() float64 {
+	if x != nil {
+		return x.CommandDurationMs
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetDnsDurationMs() float64 {
+	if x != nil {
+		return x.DnsDurationMs
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetParseDurationMs() float64 {
+	if x != nil {
+		return x.ParseDurationMs
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetIterationCount() int32 {
+	if x != nil {
+		return x.IterationCount
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetElapsedTimeNanos() int64 {
+	if x != nil {
+		return x.ElapsedTimeNanos
+	}
+	return 0
+}
+
+func (x *TracerouteResult) GetPathChanged() bool {
+	if x != nil {
+		return x.PathChanged
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetChangedHops() []int32 {
+	if x != nil {
+		return x.ChangedHops
+	}
+	return nil
+}
+
+func (x *TracerouteResult) GetConverged() bool {
+	if x != nil {
+		return x.Converged
+	}
+	return false
+}
+
+func (x *TracerouteResult) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetPaths() []*TracerouteResult {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *TracerouteResult) GetDnsServer() string {
+	if x != nil {
+		return x.DnsServer
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetSelectedInterface() string {
+	if x != nil {
+		return x.SelectedInterface
+	}
+	return ""
+}
+
+func (x *TracerouteResult) GetSelectedSourceIp() string {
+	if x != nil {
+		return x.SelectedSourceIp
+	}
+	return ""
+}
+
+type HopStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AvgRtt        float64                `protobuf:"fixed64,1,opt,name=avg_rtt,json=avgRtt,proto3" json:"avg_rtt,omitempty"`
+	MinRtt        float64                `protobuf:"fixed64,2,opt,name=min_rtt,json=minRtt,proto3" json:"min_rtt,omitempty"`
+	MaxRtt        float64                `protobuf:"fixed64,3,opt,name=max_rtt,json=maxRtt,proto3" json:"max_rtt,omitempty"`
+	StdDevRtt     float64                `protobuf:"fixed64,4,opt,name=std_dev_rtt,json=stdDevRtt,proto3" json:"std_dev_rtt,omitempty"`
+	Jitter        float64                `protobuf:"fixed64,5,opt,name=jitter,proto3" json:"jitter,omitempty"`
+	LossPercent   float64                `protobuf:"fixed64,6,opt,name=loss_percent,json=lossPercent,proto3" json:"loss_percent,omitempty"`
+	ResponseRate  float64                `protobuf:"fixed64,7,opt,name=response_rate,json=responseRate,proto3" json:"response_rate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HopStats) Reset() {
+	*x = HopStats{}
+	mi := &file_traceroute_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HopStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HopStats) ProtoMessage() {}
+
+func (x *HopStats) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HopStats.ProtoReflect.Descriptor instead.
+func (*HopStats) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HopStats) GetAvgRtt() float64 {
+	if x != nil {
+		return x.AvgRtt
+	}
+	return 0
+}
+
+func (x *HopStats) GetMinRtt() float64 {
+	if x != nil {
+		return x.MinRtt
+	}
+	return 0
+}
+
+func (x *HopStats) GetMaxRtt() float64 {
+	if x != nil {
+		return x.MaxRtt
+	}
+	return 0
+}
+
+func (x *HopStats) GetStdDevRtt() float64 {
+	if x != nil {
+		return x.StdDevRtt
+	}
+	return 0
+}
+
+func (x *HopStats) GetJitter() float64 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
+func (x *HopStats) GetLossPercent() float64 {
+	if x != nil {
+		return x.LossPercent
+	}
+	return 0
+}
+
+func (x *HopStats) GetResponseRate() float64 {
+	if x != nil {
+		return x.ResponseRate
+	}
+	return 0
+}
+
+type IterationStats struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TotalIterations int32                  `protobuf:"varint,1,opt,name=total_iterations,json=totalIterations,proto3" json:"total_iterations,omitempty"`
+	AverageHopCount float64                `protobuf:"fixed64,2,opt,name=average_hop_count,json=averageHopCount,proto3" json:"average_hop_count,omitempty"`
+	MinHopCount     int32                  `protobuf:"varint,3,opt,name=min_hop_count,json=minHopCount,proto3" json:"min_hop_count,omitempty"`
+	MaxHopCount     int32                  `protobuf:"varint,4,opt,name=max_hop_count,json=maxHopCount,proto3" json:"max_hop_count,omitempty"`
+	PathChangeCount int32                  `protobuf:"varint,5,opt,name=path_change_count,json=pathChangeCount,proto3" json:"path_change_count,omitempty"`
+	PerHopStats     map[int32]*HopStats    `protobuf:"bytes,6,rep,name=per_hop_stats,json=perHopStats,proto3" json:"per_hop_stats,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *IterationStats) Reset() {
+	*x = IterationStats{}
+	mi := &file_traceroute_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IterationStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IterationStats) ProtoMessage() {}
+
+func (x *IterationStats) ProtoReflect() protoreflect.Message {
+	mi := &file_traceroute_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IterationStats.ProtoReflect.Descriptor instead.
+func (*IterationStats) Descriptor() ([]byte, []int) {
+	return file_traceroute_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IterationStats) GetTotalIterations() int32 {
+	if x != nil {
+		return x.TotalIterations
+	}
+	return 0
+}
+
+func (x *IterationStats) GetAverageHopCount() float64 {
+	if x != nil {
+		return x.AverageHopCount
+	}
+	return 0
+}
+
+func (x *IterationStats) GetMinHopCount() int32 {
+	if x != nil {
+		return x.MinHopCount
+	}
+	return 0
+}
+
+func (x *IterationStats) GetMaxHopCount() int32 {
+	if x != nil {
+		return x.MaxHopCount
+	}
+	return 0
+}
+
+func (x *IterationStats) GetPathChangeCount() int32 {
+	if x != nil {
+		return x.PathChangeCount
+	}
+	return 0
+}
+
+func (x *IterationStats) GetPerHopStats() map[int32]*HopStats {
+	if x != nil {
+		return x.PerHopStats
+	}
+	return nil
+}
+
+var File_traceroute_proto protoreflect.FileDescriptor
+
+const file_traceroute_proto_rawDesc = "" +
+	"\n" +
+	"\x10traceroute.proto\x12\x16netscout.traceroute.v1\"\xae\x02\n" +
+	"\x11TracerouteRequest\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x19\n" +
+	"\bmax_hops\x18\x02 \x01(\x05R\amaxHops\x12\x1f\n" +
+	"\vprobe_count\x18\x03 \x01(\x05R\n" +
+	"probeCount\x12\x1a\n" +
+	"\bprotocol\x18\x04 \x01(\tR\bprotocol\x12\x12\n" +
+	"\x04port\x18\x05 \x01(\x05R\x04port\x12\x1d\n" +
+	"\n" +
+	"use_native\x18\x06 \x01(\bR\tuseNative\x12$\n" +
+	"\vresolve_dns\x18\a \x01(\bH\x00R\n" +
+	"resolveDns\x88\x01\x01\x12'\n" +
+	"\x0foverall_timeout\x18\b \x01(\x01R\x0eoverallTimeout\x12\x1b\n" +
+	"\tfirst_hop\x18\t \x01(\x05R\bfirstHopB\x0e\n" +
+	"\f_resolve_dns\"\xe0\x01\n" +
+	"\x03Hop\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x15\n" +
+	"\x06rtt_ms\x18\x04 \x01(\x01R\x05rttMs\x12!\n" +
+	"\floss_percent\x18\x05 \x01(\x01R\vlossPercent\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x10\n" +
+	"\x03asn\x18\a \x01(\x05R\x03asn\x12\x18\n" +
+	"\acountry\x18\b \x01(\tR\acountry\x12\x1f\n" +
+	"\vrtt_samples\x18\t \x03(\x01R\n" +
+	"rttSamples\"\xfe\x01\n" +
+	"\x12TracerouteResponse\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12/\n" +
+	"\x04hops\x18\x02 \x03(\v2\x1b.netscout.traceroute.v1.HopR\x04hops\x12%\n" +
+	"\x0eaddress_family\x18\x03 \x01(\tR\raddressFamily\x12/\n" +
+	"\x13destination_reached\x18\x04 \x01(\bR\x12destinationReached\x12$\n" +
+	"\x0ereached_at_hop\x18\x05 \x01(\x05R\freachedAtHop\x12%\n" +
+	"\x0etimestamp_unix\x18\x06 \x01(\x03R\rtimestampUnix\"M\n" +
+	"\bHopEvent\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12-\n" +
+	"\x03hop\x18\x02 \x01(\v2\x1b.netscout.traceroute.v1.HopR\x03hop\"[\n" +
+	"\tMplsLabel\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\rR\x05label\x12\x10\n" +
+	"\x03exp\x18\x02 \x01(\rR\x03exp\x12\x14\n" +
+	"\x05stack\x18\x03 \x01(\bR\x05stack\x12\x10\n" +
+	"\x03ttl\x18\x04 \x01(\rR\x03ttl\"\xb3\x05\n" +
+	"\tHopResult\x12\x10\n" +
+	"\x03hop\x18\x01 \x01(\x05R\x03hop\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x10\n" +
+	"\x03rtt\x18\x04 \x01(\x01R\x03rtt\x12\x1f\n" +
+	"\vrtt_samples\x18\x05 \x03(\x01R\n" +
+	"rttSamples\x12\x17\n" +
+	"\artt_min\x18\x06 \x01(\x01R\x06rttMin\x12\x17\n" +
+	"\artt_max\x18\a \x01(\x01R\x06rttMax\x12\x17\n" +
+	"\artt_avg\x18\b \x01(\x01R\x06rttAvg\x12\x1e\n" +
+	"\vrtt_std_dev\x18\t \x01(\x01R\trttStdDev\x12\x16\n" +
+	"\x06jitter\x18\n" +
+	" \x01(\x01R\x06jitter\x12\x1f\n" +
+	"\vprobes_sent\x18\v \x01(\x05R\n" +
+	"probesSent\x12\x12\n" +
+	"\x04loss\x18\f \x01(\x01R\x04loss\x12%\n" +
+	"\x0eprobe_protocol\x18\r \x01(\tR\rprobeProtocol\x129\n" +
+	"\x06status\x18\x0e \x01(\x0e2!.netscout.traceroute.v1.HopStatusR\x06status\x12\x10\n" +
+	"\x03mtu\x18\x0f \x01(\x05R\x03mtu\x12 \n" +
+	"\fnext_hop_mtu\x18\x10 \x01(\x05R\n" +
+	"nextHopMtu\x12\x10\n" +
+	"\x03asn\x18\x11 \x01(\x05R\x03asn\x12\x17\n" +
+	"\aasn_org\x18\x12 \x01(\tR\x06asnOrg\x12\x18\n" +
+	"\acountry\x18\x13 \x01(\tR\acountry\x12\x12\n" +
+	"\x04city\x18\x14 \x01(\tR\x04city\x12\x1a\n" +
+	"\blatitude\x18\x15 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x16 \x01(\x01R\tlongitude\x12\x18\n" +
+	"\aretries\x18\x17 \x01(\x05R\aretries\x12B\n" +
+	"\vmpls_labels\x18\x18 \x03(\v2!.netscout.traceroute.v1.MplsLabelR\n" +
+	"mplsLabels\"\xab\v\n" +
+	"\x10TracerouteResult\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12'\n" +
+	"\x0fnormalized_host\x18\x02 \x01(\tR\x0enormalizedHost\x12#\n" +
+	"\rresolved_host\x18\x03 \x01(\tR\fresolvedHost\x12!\n" +
+	"\fexecution_id\x18\x04 \x01(\tR\vexecutionId\x12%\n" +
+	"\x0ecorrelation_id\x18\x05 \x01(\tR\rcorrelationId\x12%\n" +
+	"\x0eplugin_version\x18\x06 \x01(\tR\rpluginVersion\x12%\n" +
+	"\x0eschema_version\x18\a \x01(\tR\rschemaVersion\x125\n" +
+	"\x04hops\x18\b \x03(\v2!.netscout.traceroute.v1.HopResultR\x04hops\x12%\n" +
+	"\x0eaddress_family\x18\t \x01(\tR\raddressFamily\x12%\n" +
+	"\x0esource_address\x18\n" +
+	" \x01(\tR\rsourceAddress\x12\x1f\n" +
+	"\vpacket_size\x18\v \x01(\x05R\n" +
+	"packetSize\x12\x19\n" +
+	"\btos_used\x18\f \x01(\x05R\atosUsed\x12\x17\n" +
+	"\aflow_id\x18\r \x01(\x05R\x06flowId\x12\x19\n" +
+	"\bpath_mtu\x18\x0e \x01(\x05R\apathMtu\x12*\n" +
+	"\x11probe_source_port\x18\x0f \x01(\x05R\x0fprobeSourcePort\x12&\n" +
+	"\x0fprobe_dest_port\x18\x10 \x01(\x05R\rprobeDestPort\x12.\n" +
+	"\x13timestamp_unix_nano\x18\x11 \x01(\x03R\x11timestampUnixNano\x12/\n" +
+	"\x13destination_reached\x18\x12 \x01(\bR\x12destinationReached\x12$\n" +
+	"\x0ereached_at_hop\x18\x13 \x01(\x05R\freachedAtHop\x12\x1c\n" +
+	"\ttruncated\x18\x14 \x01(\bR\ttruncated\x12\x19\n" +
+	"\bhas_mpls\x18\x15 \x01(\bR\ahasMpls\x12\x19\n" +
+	"\bhas_loop\x18\x16 \x01(\bR\ahasLoop\x12\x1a\n" +
+	"\bwarnings\x18\x17 \x03(\tR\bwarnings\x12\x1d\n" +
+	"\n" +
+	"has_alerts\x18\x18 \x01(\bR\thasAlerts\x12!\n" +
+	"\fsla_breached\x18\x19 \x01(\bR\vslaBreached\x12.\n" +
+	"\x13command_duration_ms\x18\x1a \x01(\x01R\x11commandDurationMs\x12&\n" +
+	"\x0fdns_duration_ms\x18\x1b \x01(\x01R\rdnsDurationMs\x12*\n" +
+	"\x11parse_duration_ms\x18\x1c \x01(\x01R\x0fparseDurationMs\x12'\n" +
+	"\x0fiteration_count\x18\x1d \x01(\x05R\x0eiterationCount\x12,\n" +
+	"\x12elapsed_time_nanos\x18\x1e \x01(\x03R\x10elapsedTimeNanos\x12!\n" +
+	"\fpath_changed\x18\x1f \x01(\bR\vpathChanged\x12!\n" +
+	"\fchanged_hops\x18  \x03(\x05R\vchangedHops\x12\x1c\n" +
+	"\tconverged\x18! \x01(\bR\tconverged\x12 \n" +
+	"\vfingerprint\x18\" \x01(\tR\vfingerprint\x12>\n" +
+	"\x05paths\x18# \x03(\v2(.netscout.traceroute.v1.TracerouteResultR\x05paths\x12\x1d\n" +
+	"\n" +
+	"dns_server\x18$ \x01(\tR\tdnsServer\x12-\n" +
+	"\x12selected_interface\x18% \x01(\tR\x11selectedInterface\x12,\n" +
+	"\x12selected_source_ip\x18& \x01(\tR\x10selectedSourceIp\"\xd5\x01\n" +
+	"\bHopStats\x12\x17\n" +
+	"\aavg_rtt\x18\x01 \x01(\x01R\x06avgRtt\x12\x17\n" +
+	"\amin_rtt\x18\x02 \x01(\x01R\x06minRtt\x12\x17\n" +
+	"\amax_rtt\x18\x03 \x01(\x01R\x06maxRtt\x12\x1e\n" +
+	"\vstd_dev_rtt\x18\x04 \x01(\x01R\tstdDevRtt\x12\x16\n" +
+	"\x06jitter\x18\x05 \x01(\x01R\x06jitter\x12!\n" +
+	"\floss_percent\x18\x06 \x01(\x01R\vlossPercent\x12#\n" +
+	"\rresponse_rate\x18\a \x01(\x01R\fresponseRate\"\x9a\x03\n" +
+	"\x0eIterationStats\x12)\n" +
+	"\x10total_iterations\x18\x01 \x01(\x05R\x0ftotalIterations\x12*\n" +
+	"\x11average_hop_count\x18\x02 \x01(\x01R\x0faverageHopCount\x12\"\n" +
+	"\rmin_hop_count\x18\x03 \x01(\x05R\vminHopCount\x12\"\n" +
+	"\rmax_hop_count\x18\x04 \x01(\x05R\vmaxHopCount\x12*\n" +
+	"\x11path_change_count\x18\x05 \x01(\x05R\x0fpathChangeCount\x12[\n" +
+	"\rper_hop_stats\x18\x06 \x03(\v27.netscout.traceroute.v1.IterationStats.PerHopStatsEntryR\vperHopStats\x1a`\n" +
+	"\x10PerHopStatsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x05R\x03key\x126\n" +
+	"\x05value\x18\x02 \x01(\v2 .netscout.traceroute.v1.HopStatsR\x05value:\x028\x01*\xa8\x01\n" +
+	"\tHopStatus\x12\x11\n" +
+	"\rHOP_STATUS_OK\x10\x00\x12\x1a\n" +
+	"\x16HOP_STATUS_NO_RESPONSE\x10\x01\x12\x16\n" +
+	"\x12HOP_STATUS_PARTIAL\x10\x02\x12\x13\n" +
+	"\x0fHOP_STATUS_LOOP\x10\x03\x12#\n" +
+	"\x1fHOP_STATUS_FRAGMENTATION_NEEDED\x10\x04\x12\x1a\n" +
+	"\x16HOP_STATUS_UNREACHABLE\x10\x052\xd3\x01\n" +
+	"\x11TracerouteService\x12a\n" +
+	"\bRunTrace\x12).netscout.traceroute.v1.TracerouteRequest\x1a*.netscout.traceroute.v1.TracerouteResponse\x12[\n" +
+	"\n" +
+	"WatchTrace\x12).netscout.traceroute.v1.TracerouteRequest\x1a .netscout.traceroute.v1.HopEvent0\x01B=Z;github.com/NetScout-Go/Plugin_traceroute/proto;traceroutepbb\x06proto3"
+
+var (
+	file_traceroute_proto_rawDescOnce sync.Once
+	file_traceroute_proto_rawDescData []byte
+)
+
+func file_traceroute_proto_rawDescGZIP() []byte {
+	file_traceroute_proto_rawDescOnce.Do(func() {
+		file_traceroute_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_traceroute_proto_rawDesc), len(file_traceroute_proto_rawDesc)))
+	})
+	return file_traceroute_proto_rawDescData
+}
+
+var file_traceroute_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_traceroute_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_traceroute_proto_goTypes = []any{
+	(HopStatus)(0),             // 0: netscout.traceroute.v1.HopStatus
+	(*TracerouteRequest)(nil),  // 1: netscout.traceroute.v1.TracerouteRequest
+	(*Hop)(nil),                // 2: netscout.traceroute.v1.Hop
+	(*TracerouteResponse)(nil), // 3: netscout.traceroute.v1.TracerouteResponse
+	(*HopEvent)(nil),           // 4: netscout.traceroute.v1.HopEvent
+	(*MplsLabel)(nil),          // 5: netscout.traceroute.v1.MplsLabel
+	(*HopResult)(nil),          // 6: netscout.traceroute.v1.HopResult
+	(*TracerouteResult)(nil),   // 7: netscout.traceroute.v1.TracerouteResult
+	(*HopStats)(nil),           // 8: netscout.traceroute.v1.HopStats
+	(*IterationStats)(nil),     // 9: netscout.traceroute.v1.IterationStats
+	nil,                        // 10: netscout.traceroute.v1.IterationStats.PerHopStatsEntry
+}
+var file_traceroute_proto_depIdxs = []int32{
+	2,  // 0: netscout.traceroute.v1.TracerouteResponse.hops:type_name -> netscout.traceroute.v1.Hop
+	2,  // 1: netscout.traceroute.v1.HopEvent.hop:type_name -> netscout.traceroute.v1.Hop
+	0,  // 2: netscout.traceroute.v1.HopResult.status:type_name -> netscout.traceroute.v1.HopStatus
+	5,  // 3: netscout.traceroute.v1.HopResult.mpls_labels:type_name -> netscout.traceroute.v1.MplsLabel
+	6,  // 4: netscout.traceroute.v1.TracerouteResult.hops:type_name -> netscout.traceroute.v1.HopResult
+	7,  // 5: netscout.traceroute.v1.TracerouteResult.paths:type_name -> netscout.traceroute.v1.TracerouteResult
+	10, // 6: netscout.traceroute.v1.IterationStats.per_hop_stats:type_name -> netscout.traceroute.v1.IterationStats.PerHopStatsEntry
+	8,  // 7: netscout.traceroute.v1.IterationStats.PerHopStatsEntry.value:type_name -> netscout.traceroute.v1.HopStats
+	1,  // 8: netscout.traceroute.v1.TracerouteService.RunTrace:input_type -> netscout.traceroute.v1.TracerouteRequest
+	1,  // 9: netscout.traceroute.v1.TracerouteService.WatchTrace:input_type -> netscout.traceroute.v1.TracerouteRequest
+	3,  // 10: netscout.traceroute.v1.TracerouteService.RunTrace:output_type -> netscout.traceroute.v1.TracerouteResponse
+	4,  // 11: netscout.traceroute.v1.TracerouteService.WatchTrace:output_type -> netscout.traceroute.v1.HopEvent
+	10, // [10:12] is the sub-list for method output_type
+	8,  // [8:10] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_traceroute_proto_init() }
+func file_traceroute_proto_init() {
+	if File_traceroute_proto != nil {
+		return
+	}
+	file_traceroute_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_traceroute_proto_rawDesc), len(file_traceroute_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_traceroute_proto_goTypes,
+		DependencyIndexes: file_traceroute_proto_depIdxs,
+		EnumInfos:         file_traceroute_proto_enumTypes,
+		MessageInfos:      file_traceroute_proto_msgTypes,
+	}.Build()
+	File_traceroute_proto = out.File
+	file_traceroute_proto_goTypes = nil
+	file_traceroute_proto_depIdxs = nil
+}
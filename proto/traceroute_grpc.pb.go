@@ -0,0 +1,175 @@
+// TracerouteService is served by the plugin when started with
+// --grpc=<address>. The server speaks gRPC over HTTP/2 without TLS.
+//
+// The Go package traceroutepb is generated from this file, run go generate
+// in the module root after changing it. Client stubs for other languages can
+// be generated with protoc as well. grpc_test.go calls the service with a
+// grpc-go client using this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: traceroute.proto
+
+package traceroutepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TracerouteService_RunTrace_FullMethodName   = "/netscout.traceroute.v1.TracerouteService/RunTrace"
+	TracerouteService_WatchTrace_FullMethodName = "/netscout.traceroute.v1.TracerouteService/WatchTrace"
+)
+
+// TracerouteServiceClient is the client API for TracerouteService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TracerouteServiceClient interface {
+	// RunTrace runs a single trace and returns the complete result.
+	RunTrace(ctx context.Context, in *TracerouteRequest, opts ...grpc.CallOption) (*TracerouteResponse, error)
+	// WatchTrace runs a single trace and streams each hop as it is discovered.
+	WatchTrace(ctx context.Context, in *TracerouteRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HopEvent], error)
+}
+
+type tracerouteServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTracerouteServiceClient(cc grpc.ClientConnInterface) TracerouteServiceClient {
+	return &tracerouteServiceClient{cc}
+}
+
+func (c *tracerouteServiceClient) RunTrace(ctx context.Context, in *TracerouteRequest, opts ...grpc.CallOption) (*TracerouteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TracerouteResponse)
+	err := c.cc.Invoke(ctx, TracerouteService_RunTrace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tracerouteServiceClient) WatchTrace(ctx context.Context, in *TracerouteRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HopEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TracerouteService_ServiceDesc.Streams[0], TracerouteService_WatchTrace_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TracerouteRequest, HopEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TracerouteService_WatchTraceClient = grpc.ServerStreamingClient[HopEvent]
+
+// TracerouteServiceServer is the server API for TracerouteService service.
+// All implementations must embed UnimplementedTracerouteServiceServer
+// for forward compatibility.
+type TracerouteServiceServer interface {
+	// RunTrace runs a single trace and returns the complete result.
+	RunTrace(context.Context, *TracerouteRequest) (*TracerouteResponse, error)
+	// WatchTrace runs a single trace and streams each hop as it is discovered.
+	WatchTrace(*TracerouteRequest, grpc.ServerStreamingServer[HopEvent]) error
+	mustEmbedUnimplementedTracerouteServiceServer()
+}
+
+// UnimplementedTracerouteServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTracerouteServiceServer struct{}
+
+func (UnimplementedTracerouteServiceServer) RunTrace(context.Context, *TracerouteRequest) (*TracerouteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RunTrace not implemented")
+}
+func (UnimplementedTracerouteServiceServer) WatchTrace(*TracerouteRequest, grpc.ServerStreamingServer[HopEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchTrace not implemented")
+}
+func (UnimplementedTracerouteServiceServer) mustEmbedUnimplementedTracerouteServiceServer() {}
+func (UnimplementedTracerouteServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeTracerouteServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TracerouteServiceServer will
+// result in compilation errors.
+type UnsafeTracerouteServiceServer interface {
+	mustEmbedUnimplementedTracerouteServiceServer()
+}
+
+func RegisterTracerouteServiceServer(s grpc.ServiceRegistrar, srv TracerouteServiceServer) {
+	// If the following call panics, it indicates UnimplementedTracerouteServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TracerouteService_ServiceDesc, srv)
+}
+
+func _TracerouteService_RunTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TracerouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TracerouteServiceServer).RunTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TracerouteService_RunTrace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TracerouteServiceServer).RunTrace(ctx, req.(*TracerouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TracerouteService_WatchTrace_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TracerouteRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TracerouteServiceServer).WatchTrace(m, &grpc.GenericServerStream[TracerouteRequest, HopEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TracerouteService_WatchTraceServer = grpc.ServerStreamingServer[HopEvent]
+
+// TracerouteService_ServiceDesc is the grpc.ServiceDesc for TracerouteService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TracerouteService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "netscout.traceroute.v1.TracerouteService",
+	HandlerType: (*TracerouteServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RunTrace",
+			Handler:    _TracerouteService_RunTrace_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTrace",
+			Handler:       _TracerouteService_WatchTrace_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "traceroute.proto",
+}
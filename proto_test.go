@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	traceroutepb "github.com/NetScout-Go/Plugin_traceroute/proto"
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// tracerouteProto compiles proto/traceroute.proto, the schema the generated
+// code in proto/ must be up to date with
+func tracerouteProto(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{"proto"}}),
+	}
+	files, err := compiler.Compile(context.Background(), "traceroute.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files[0]
+}
+
+// decodeProto decodes b as the message name of proto/traceroute.proto and
+// fails the test if any field is unknown to the schema
+func decodeProto(t *testing.T, file protoreflect.FileDescriptor, name string, b []byte) *dynamicpb.Message {
+	t.Helper()
+	desc := file.Messages().ByName(protoreflect.Name(name))
+	if desc == nil {
+		t.Fatalf("proto/traceroute.proto has no message %s", name)
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		t.Fatalf("failed to decode %s: %v", name, err)
+	}
+	checkNoUnknownFields(t, msg, name)
+	return msg
+}
+
+func checkNoUnknownFields(t *testing.T, msg protoreflect.Message, path string) {
+	t.Helper()
+	if len(msg.GetUnknown()) > 0 {
+		t.Errorf("%s has fields unknown to the schema", path)
+	}
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		child := path + "." + string(fd.Name())
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, entry protoreflect.Value) bool {
+					checkNoUnknownFields(t, entry.Message(), child)
+					return true
+				})
+			}
+		case fd.Message() == nil:
+		case fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				checkNoUnknownFields(t, v.List().Get(i).Message(), child)
+			}
+		default:
+			checkNoUnknownFields(t, v.Message(), child)
+		}
+		return true
+	})
+}
+
+// checkProtoJSON compares the proto-json output of the plugin with the
+// canonical JSON mapping of msg
+func checkProtoJSON(t *testing.T, got []byte, msg proto.Message) {
+	t.Helper()
+	canonical, err := protojson.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotDoc, wantDoc interface{}
+	if err := json.Unmarshal(got, &gotDoc); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(canonical, &wantDoc); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotDoc, wantDoc) {
+		t.Errorf("proto-json differs from the canonical mapping:\n got %s\nwant %s", got, canonical)
+	}
+}
+
+// TestGeneratedProtoUpToDate fails when proto/traceroute.proto changed
+// without running go generate
+func TestGeneratedProtoUpToDate(t *testing.T) {
+	got := protodesc.ToFileDescriptorProto(traceroutepb.File_traceroute_proto)
+	want := protodesc.ToFileDescriptorProto(tracerouteProto(t))
+	got.SourceCodeInfo, want.SourceCodeInfo = nil, nil
+	if !proto.Equal(got, want) {
+		t.Error("proto/traceroute.pb.go is out of date, run go generate")
+	}
+}
+
+func TestMarshalProtoMatchesSchema(t *testing.T) {
+	file := tracerouteProto(t)
+	result := testResult()
+	result.SelectedInterface = "eth0"
+	result.SelectedSourceIP = "192.168.1.20"
+	data, err := result.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := decodeProto(t, file, "TracerouteResult", data)
+	fields := msg.Descriptor().Fields()
+	for name, want := range map[string]interface{}{
+		"host":                "example.com",
+		"execution_id":        "6f1c2a4e-8b3d-4c5e-9f60-7a8b9c0d1e2f",
+		"timestamp_unix_nano": result.Timestamp.UnixNano(),
+		"elapsed_time_nanos":  int64(result.ElapsedTime),
+		"has_loop":            true,
+		"selected_interface":  "eth0",
+	} {
+		if got := msg.Get(fields.ByName(protoreflect.Name(name))).Interface(); got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	hops := msg.Get(fields.ByName("hops")).List()
+	if hops.Len() != len(result.Hops) {
+		t.Fatalf("got %d hops, want %d", hops.Len(), len(result.Hops))
+	}
+	hop := hops.Get(2).Message()
+	hopFields := hop.Descriptor().Fields()
+	if got := hop.Get(hopFields.ByName("status")).Enum(); got != 2 {
+		t.Errorf("hop 3: got status %d, want HOP_STATUS_PARTIAL", got)
+	}
+	label := hop.Get(hopFields.ByName("mpls_labels")).List().Get(0).Message()
+	if got := label.Get(label.Descriptor().Fields().ByName("label")).Uint(); got != 24001 {
+		t.Errorf("hop 3: got MPLS label %d, want 24001", got)
+	}
+
+	var gotJSON bytes.Buffer
+	if err := writeResult(&gotJSON, result, "proto-json", false); err != nil {
+		t.Fatal(err)
+	}
+	checkProtoJSON(t, gotJSON.Bytes(), msg)
+
+	// A message encoded from the schema decodes to the same result
+	reencoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalProto(reencoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := UnmarshalProto(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("re-encoded message decodes to\n%+v\nwant\n%+v", got, want)
+	}
+	if want.Host != result.Host || !want.Timestamp.Equal(result.Timestamp) || len(want.Paths) != 1 ||
+		!reflect.DeepEqual(want.Hops[2].MPLSLabels, result.Hops[2].MPLSLabels) {
+		t.Errorf("UnmarshalProto does not return the encoded result: %+v", want)
+	}
+}
+
+func TestMarshalProtoStatsMatchesSchema(t *testing.T) {
+	file := tracerouteProto(t)
+	stats := IterationStats{
+		TotalIterations: 3,
+		AverageHopCount: 4.5,
+		MinHopCount:     4,
+		MaxHopCount:     5,
+		PathChangeCount: 1,
+		PerHopStats: map[int]HopStats{
+			1: {AvgRTT: 1.5, MinRTT: 1.2, MaxRTT: 1.8, StdDevRTT: 0.2, Jitter: 0.3, ResponseRate: 100},
+			3: {AvgRTT: 12.25, LossPercent: 33.3, ResponseRate: 66.7},
+		},
+	}
+	data, err := stats.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := decodeProto(t, file, "IterationStats", data)
+	perHop := msg.Get(msg.Descriptor().Fields().ByName("per_hop_stats")).Map()
+	if perHop.Len() != 2 {
+		t.Fatalf("got %d per hop entries, want 2", perHop.Len())
+	}
+	entry := perHop.Get(protoreflect.ValueOfInt32(3).MapKey()).Message()
+	if got := entry.Get(entry.Descriptor().Fields().ByName("avg_rtt")).Float(); got != 12.25 {
+		t.Errorf("hop 3: got average RTT %v, want 12.25", got)
+	}
+
+	var gotJSON bytes.Buffer
+	if err := writeProtoJSONResult(&gotJSON, statsToProto(stats), true); err != nil {
+		t.Fatal(err)
+	}
+	checkProtoJSON(t, gotJSON.Bytes(), msg)
+}
@@ -0,0 +1,244 @@
+package main
+
+//go:generate protoc --proto_path=proto --go_out=proto --go_opt=paths=source_relative --go-grpc_out=proto --go-grpc_opt=paths=source_relative traceroute.proto
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	traceroutepb "github.com/NetScout-Go/Plugin_traceroute/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// MarshalProto encodes the result as a TracerouteResult message of
+// proto/traceroute.proto. The message carries the trace and its iteration
+// summary, the analysis sections of the JSON output such as alerts, SLA
+// violations and history are left out.
+func (r TracerouteResult) MarshalProto() ([]byte, error) {
+	return proto.Marshal(resultToProto(r))
+}
+
+// UnmarshalProto decodes a TracerouteResult message written by MarshalProto
+func UnmarshalProto(b []byte) (TracerouteResult, error) {
+	var msg traceroutepb.TracerouteResult
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		return TracerouteResult{}, fmt.Errorf("failed to parse traceroute result: %v", err)
+	}
+	return resultFromProto(&msg), nil
+}
+
+// MarshalProto encodes the statistics as an IterationStats message of
+// proto/traceroute.proto. Hops are encoded in a stable order.
+func (s IterationStats) MarshalProto() ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(statsToProto(s))
+}
+
+// statsToProto converts the statistics to an IterationStats message
+func statsToProto(s IterationStats) *traceroutepb.IterationStats {
+	msg := &traceroutepb.IterationStats{
+		TotalIterations: int32(s.TotalIterations),
+		AverageHopCount: s.AverageHopCount,
+		MinHopCount:     int32(s.MinHopCount),
+		MaxHopCount:     int32(s.MaxHopCount),
+		PathChangeCount: int32(s.PathChangeCount),
+	}
+	if len(s.PerHopStats) > 0 {
+		msg.PerHopStats = make(map[int32]*traceroutepb.HopStats, len(s.PerHopStats))
+		for hop, stats := range s.PerHopStats {
+			msg.PerHopStats[int32(hop)] = &traceroutepb.HopStats{
+				AvgRtt:       stats.AvgRTT,
+				MinRtt:       stats.MinRTT,
+				MaxRtt:       stats.MaxRTT,
+				StdDevRtt:    stats.StdDevRTT,
+				Jitter:       stats.Jitter,
+				LossPercent:  stats.LossPercent,
+				ResponseRate: stats.ResponseRate,
+			}
+		}
+	}
+	return msg
+}
+
+// resultToProto converts a result to a TracerouteResult message
+func resultToProto(r TracerouteResult) *traceroutepb.TracerouteResult {
+	msg := &traceroutepb.TracerouteResult{
+		Host:               r.Host,
+		NormalizedHost:     r.NormalizedHost,
+		ResolvedHost:       r.ResolvedHost,
+		ExecutionId:        r.ExecutionID,
+		CorrelationId:      r.CorrelationID,
+		PluginVersion:      r.PluginVersion,
+		SchemaVersion:      r.SchemaVersion,
+		AddressFamily:      r.AddressFamily,
+		SourceAddress:      r.SourceAddress,
+		PacketSize:         int32(r.PacketSize),
+		TosUsed:            int32(r.TOSUsed),
+		FlowId:             int32(r.FlowID),
+		PathMtu:            int32(r.PathMTU),
+		ProbeSourcePort:    int32(r.ProbeSourcePort),
+		ProbeDestPort:      int32(r.ProbeDestPort),
+		DestinationReached: r.DestinationReached,
+		ReachedAtHop:       int32(r.ReachedAtHop),
+		Truncated:          r.Truncated,
+		HasMpls:            r.HasMPLS,
+		HasLoop:            r.HasLoop,
+		Warnings:           r.Warnings,
+		HasAlerts:          r.HasAlerts,
+		SlaBreached:        r.SLABreached,
+		CommandDurationMs:  r.CommandDurationMs,
+		DnsDurationMs:      r.DNSDurationMs,
+		ParseDurationMs:    r.ParseDurationMs,
+		IterationCount:     int32(r.IterationCount),
+		ElapsedTimeNanos:   int64(r.ElapsedTime),
+		PathChanged:        r.PathChanged,
+		Converged:          r.Converged,
+		Fingerprint:        r.Fingerprint,
+		DnsServer:          r.DNSServer,
+		SelectedInterface:  r.SelectedInterface,
+		SelectedSourceIp:   r.SelectedSourceIP,
+	}
+	if !r.Timestamp.IsZero() {
+		msg.TimestampUnixNano = r.Timestamp.UnixNano()
+	}
+	for _, hop := range r.Hops {
+		msg.Hops = append(msg.Hops, hopResultToProto(hop))
+	}
+	for _, hop := range r.ChangedHops {
+		msg.ChangedHops = append(msg.ChangedHops, int32(hop))
+	}
+	for _, path := range r.Paths {
+		msg.Paths = append(msg.Paths, resultToProto(path))
+	}
+	return msg
+}
+
+// hopResultToProto converts a hop to a HopResult message
+func hopResultToProto(hop HopResult) *traceroutepb.HopResult {
+	msg := &traceroutepb.HopResult{
+		Hop:           int32(hop.Hop),
+		Ip:            hop.IP,
+		Name:          hop.Name,
+		Rtt:           hop.RTT,
+		RttSamples:    hop.RTTSamples,
+		RttMin:        hop.RTTMin,
+		RttMax:        hop.RTTMax,
+		RttAvg:        hop.RTTAvg,
+		RttStdDev:     hop.RTTStdDev,
+		Jitter:        hop.Jitter,
+		ProbesSent:    int32(hop.ProbesSent),
+		Loss:          hop.Loss,
+		ProbeProtocol: hop.ProbeProtocol,
+		Status:        traceroutepb.HopStatus(hop.Status),
+		Mtu:           int32(hop.MTU),
+		NextHopMtu:    int32(hop.NextHopMTU),
+		Asn:           int32(hop.ASN),
+		AsnOrg:        hop.ASNOrg,
+		Country:       hop.Country,
+		City:          hop.City,
+		Latitude:      hop.Latitude,
+		Longitude:     hop.Longitude,
+		Retries:       int32(hop.Retries),
+	}
+	for _, label := range hop.MPLSLabels {
+		msg.MplsLabels = append(msg.MplsLabels, &traceroutepb.MplsLabel{
+			Label: label.Label,
+			Exp:   uint32(label.Exp),
+			Stack: label.Stack,
+			Ttl:   uint32(label.TTL),
+		})
+	}
+	return msg
+}
+
+// resultFromProto converts a TracerouteResult message to a result
+func resultFromProto(msg *traceroutepb.TracerouteResult) TracerouteResult {
+	r := TracerouteResult{
+		Host:               msg.GetHost(),
+		NormalizedHost:     msg.GetNormalizedHost(),
+		ResolvedHost:       msg.GetResolvedHost(),
+		ExecutionID:        msg.GetExecutionId(),
+		CorrelationID:      msg.GetCorrelationId(),
+		PluginVersion:      msg.GetPluginVersion(),
+		SchemaVersion:      msg.GetSchemaVersion(),
+		AddressFamily:      msg.GetAddressFamily(),
+		SourceAddress:      msg.GetSourceAddress(),
+		PacketSize:         int(msg.GetPacketSize()),
+		TOSUsed:            int(msg.GetTosUsed()),
+		FlowID:             int(msg.GetFlowId()),
+		PathMTU:            int(msg.GetPathMtu()),
+		ProbeSourcePort:    int(msg.GetProbeSourcePort()),
+		ProbeDestPort:      int(msg.GetProbeDestPort()),
+		DestinationReached: msg.GetDestinationReached(),
+		ReachedAtHop:       int(msg.GetReachedAtHop()),
+		Truncated:          msg.GetTruncated(),
+		HasMPLS:            msg.GetHasMpls(),
+		HasLoop:            msg.GetHasLoop(),
+		Warnings:           msg.GetWarnings(),
+		HasAlerts:          msg.GetHasAlerts(),
+		SLABreached:        msg.GetSlaBreached(),
+		CommandDurationMs:  msg.GetCommandDurationMs(),
+		DNSDurationMs:      msg.GetDnsDurationMs(),
+		ParseDurationMs:    msg.GetParseDurationMs(),
+		IterationCount:     int(msg.GetIterationCount()),
+		ElapsedTime:        time.Duration(msg.GetElapsedTimeNanos()),
+		PathChanged:        msg.GetPathChanged(),
+		Converged:          msg.GetConverged(),
+		Fingerprint:        msg.GetFingerprint(),
+		DNSServer:          msg.GetDnsServer(),
+		SelectedInterface:  msg.GetSelectedInterface(),
+		SelectedSourceIP:   msg.GetSelectedSourceIp(),
+	}
+	if nanos := msg.GetTimestampUnixNano(); nanos != 0 {
+		r.Timestamp = time.Unix(0, nanos).UTC()
+	}
+	for _, hop := range msg.GetHops() {
+		r.Hops = append(r.Hops, hopResultFromProto(hop))
+	}
+	for _, hop := range msg.GetChangedHops() {
+		r.ChangedHops = append(r.ChangedHops, int(hop))
+	}
+	for _, path := range msg.GetPaths() {
+		r.Paths = append(r.Paths, resultFromProto(path))
+	}
+	return r
+}
+
+// hopResultFromProto converts a HopResult message to a hop
+func hopResultFromProto(msg *traceroutepb.HopResult) HopResult {
+	hop := HopResult{
+		Hop:           int(msg.GetHop()),
+		IP:            msg.GetIp(),
+		IPAddr:        net.ParseIP(msg.GetIp()),
+		Name:          msg.GetName(),
+		RTT:           msg.GetRtt(),
+		RTTSamples:    msg.GetRttSamples(),
+		RTTMin:        msg.GetRttMin(),
+		RTTMax:        msg.GetRttMax(),
+		RTTAvg:        msg.GetRttAvg(),
+		RTTStdDev:     msg.GetRttStdDev(),
+		Jitter:        msg.GetJitter(),
+		ProbesSent:    int(msg.GetProbesSent()),
+		Loss:          msg.GetLoss(),
+		ProbeProtocol: msg.GetProbeProtocol(),
+		Status:        HopStatus(msg.GetStatus()),
+		MTU:           int(msg.GetMtu()),
+		NextHopMTU:    int(msg.GetNextHopMtu()),
+		ASN:           int(msg.GetAsn()),
+		ASNOrg:        msg.GetAsnOrg(),
+		Country:       msg.GetCountry(),
+		City:          msg.GetCity(),
+		Latitude:      msg.GetLatitude(),
+		Longitude:     msg.GetLongitude(),
+		Retries:       int(msg.GetRetries()),
+	}
+	for _, label := range msg.GetMplsLabels() {
+		hop.MPLSLabels = append(hop.MPLSLabels, MPLSLabel{
+			Label: label.GetLabel(),
+			Exp:   uint8(label.GetExp()),
+			Stack: label.GetStack(),
+			TTL:   uint8(label.GetTtl()),
+		})
+	}
+	return hop
+}
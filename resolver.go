@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HopEnrichment is what a HopResolver found out about a hop address. Empty
+// fields leave the hop unchanged.
+type HopEnrichment struct {
+	Hostname string
+	ASN      int
+	ASNOrg   string
+	Country  string
+	City     string
+}
+
+// HopResolver looks up the details of a single hop address
+type HopResolver interface {
+	ResolveHop(ctx context.Context, ip string) (HopEnrichment, error)
+}
+
+// ResolveHops returns a copy of hops enriched by resolver. Each address is
+// resolved once even if it appears at several hops, hops that did not
+// answer are skipped and failed lookups leave the hop as it was.
+func ResolveHops(ctx context.Context, hops []HopResult, resolver HopResolver) []HopResult {
+	resolved := make([]HopResult, len(hops))
+	copy(resolved, hops)
+
+	type answer struct {
+		enrichment HopEnrichment
+		err        error
+	}
+	answers := map[string]*answer{}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, defaultDNSParallelism)
+	for _, hop := range resolved {
+		if hop.IP == "*" || answers[hop.IP] != nil {
+			continue
+		}
+		a := &answer{}
+		answers[hop.IP] = a
+
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			a.enrichment, a.err = resolver.ResolveHop(ctx, ip)
+		}(hop.IP)
+	}
+	wg.Wait()
+
+	for i := range resolved {
+		a, ok := answers[resolved[i].IP]
+		if !ok || a.err != nil {
+			continue
+		}
+		e := a.enrichment
+		if e.Hostname != "" {
+			resolved[i].Name = e.Hostname
+		}
+		if e.ASN != 0 {
+			resolved[i].ASN = e.ASN
+		}
+		if e.ASNOrg != "" {
+			resolved[i].ASNOrg = e.ASNOrg
+		}
+		if e.Country != "" {
+			resolved[i].Country = e.Country
+		}
+		if e.City != "" {
+			resolved[i].City = e.City
+		}
+	}
+	return resolved
+}
+
+// DefaultHopResolver resolves hostnames with reverse DNS and the origin AS
+// with the Team Cymru whois service, which also reports the country the
+// address is registered in. It does not know the city.
+type DefaultHopResolver struct {
+	// Timeout bounds the reverse DNS lookup, defaultDNSTimeout if zero
+	Timeout time.Duration
+}
+
+// ResolveHop implements HopResolver. Only a failure of both lookups is
+// reported as an error.
+func (r DefaultHopResolver) ResolveHop(ctx context.Context, ip string) (HopEnrichment, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultDNSTimeout
+	}
+
+	var enrichment HopEnrichment
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	names, dnsErr := net.DefaultResolver.LookupAddr(lookupCtx, ip)
+	cancel()
+	if dnsErr == nil && len(names) > 0 {
+		enrichment.Hostname = strings.TrimSuffix(names[0], ".")
+	}
+
+	answers, whoisErr := queryCymru(ctx, []string{ip})
+	if info, ok := answers[ip]; ok {
+		enrichment.ASN = info.asn
+		enrichment.ASNOrg = info.org
+		enrichment.Country = info.country
+	}
+
+	if dnsErr != nil && whoisErr != nil {
+		return HopEnrichment{}, dnsErr
+	}
+	return enrichment, nil
+}
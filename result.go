@@ -0,0 +1,379 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// HopStatus summarizes how a hop answered its probes
+type HopStatus int
+
+const (
+	// HopStatusOK is a hop that answered every probe
+	HopStatusOK HopStatus = iota
+	// HopStatusNoResponse is a hop that answered none of its probes
+	HopStatusNoResponse
+	// HopStatusPartial is a hop that answered some of its probes
+	HopStatusPartial
+	// HopStatusLoop is a hop repeating the address of an earlier hop
+	HopStatusLoop
+	// HopStatusFragmentationNeeded is a hop that could not forward a
+	// probe without fragmenting it, see dontFragment
+	HopStatusFragmentationNeeded
+	// HopStatusUnreachable is a router that answered that the target can
+	// not be reached from it
+	HopStatusUnreachable
+)
+
+// hopStatusNames are the names of the statuses in the output
+var hopStatusNames = map[HopStatus]string{
+	HopStatusOK:                  "OK",
+	HopStatusNoResponse:          "NO RESPONSE",
+	HopStatusPartial:             "PARTIAL",
+	HopStatusLoop:                "LOOP",
+	HopStatusFragmentationNeeded: "FRAGMENTATION_NEEDED",
+	HopStatusUnreachable:         "UNREACHABLE",
+}
+
+func (s HopStatus) String() string {
+	if name, ok := hopStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("HopStatus(%d)", int(s))
+}
+
+// MarshalJSON encodes the status by its name
+func (s HopStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalText encodes the status by its name, as used for XML attributes
+func (s HopStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a status name
+func (s *HopStatus) UnmarshalText(text []byte) error {
+	for status, name := range hopStatusNames {
+		if name == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown hop status %q", text)
+}
+
+// HopResult describes a single hop of a traceroute
+type HopResult struct {
+	Hop           int         `json:"hop" xml:"number,attr"`
+	IP            string      `json:"host" xml:"ip"`
+	Name          string      `json:"name" xml:"name"`
+	RTT           float64     `json:"rtt" xml:"rtt"`
+	RTTSamples    []float64   `json:"rttSamples" xml:"rttSamples>sample"`
+	RTTMin        float64     `json:"rttMin" xml:"rttMin"`
+	RTTMax        float64     `json:"rttMax" xml:"rttMax"`
+	RTTAvg        float64     `json:"rttAvg" xml:"rttAvg"`
+	RTTStdDev     float64     `json:"rttStdDev" xml:"rttStdDev"`
+	Jitter        float64     `json:"jitter" xml:"jitter"`
+	ProbesSent    int         `json:"probesSent" xml:"probesSent"`
+	Loss          float64     `json:"loss" xml:"loss"`
+	ProbeProtocol string      `json:"probeProtocol" xml:"protocol,attr"`
+	Status        HopStatus   `json:"status" xml:"status,attr"`
+	MTU           int         `json:"mtu,omitempty" xml:"mtu,omitempty"`
+	NextHopMTU    int         `json:"nextHopMTU,omitempty" xml:"nextHopMTU,omitempty"`
+	ASN           int         `json:"asn,omitempty" xml:"asn,omitempty"`
+	ASNOrg        string      `json:"asnOrg,omitempty" xml:"asnOrg,omitempty"`
+	Country       string      `json:"country,omitempty" xml:"country,omitempty"`
+	City          string      `json:"city,omitempty" xml:"city,omitempty"`
+	Latitude      float64     `json:"latitude,omitempty" xml:"latitude,omitempty"`
+	Longitude     float64     `json:"longitude,omitempty" xml:"longitude,omitempty"`
+	MPLSLabels    []MPLSLabel `json:"mplsLabels,omitempty" xml:"-"`
+	Retries       int         `json:"retries,omitempty" xml:"retries,omitempty"`
+
+	// IPAddr is IP parsed, nil for hops that did not answer. It is not
+	// encoded, decoding sets it from IP.
+	IPAddr net.IP `json:"-" xml:"-"`
+}
+
+// UnmarshalJSON decodes a hop and parses its address into IPAddr
+func (h *HopResult) UnmarshalJSON(data []byte) error {
+	type plain HopResult
+	if err := json.Unmarshal(data, (*plain)(h)); err != nil {
+		return err
+	}
+	h.IPAddr = net.ParseIP(h.IP)
+	return nil
+}
+
+// ipAddr returns the parsed address of the hop, parsing IP when the hop
+// was built without IPAddr
+func (h HopResult) ipAddr() net.IP {
+	if h.IPAddr != nil {
+		return h.IPAddr
+	}
+	return net.ParseIP(h.IP)
+}
+
+// IsPrivate reports whether the hop answered from a private address
+func (h HopResult) IsPrivate() bool {
+	return h.ipAddr().IsPrivate()
+}
+
+// IsGlobalUnicast reports whether the hop answered from a global unicast
+// address
+func (h HopResult) IsGlobalUnicast() bool {
+	return h.ipAddr().IsGlobalUnicast()
+}
+
+// IsLoopback reports whether the hop answered from a loopback address
+func (h HopResult) IsLoopback() bool {
+	return h.ipAddr().IsLoopback()
+}
+
+// TracerouteResult is the result of a single traceroute run
+type TracerouteResult struct {
+	XMLName               xml.Name                `json:"-" xml:"traceroute"`
+	Host                  string                  `json:"host" xml:"host,attr"`
+	NormalizedHost        string                  `json:"normalizedHost,omitempty" xml:"normalizedHost,attr,omitempty"`
+	ResolvedHost          string                  `json:"resolvedHost,omitempty" xml:"resolvedHost,attr,omitempty"`
+	ExecutionID           string                  `json:"executionID" xml:"executionID,attr"`
+	CorrelationID         string                  `json:"correlationID,omitempty" xml:"correlationID,attr,omitempty"`
+	PluginVersion         string                  `json:"pluginVersion" xml:"pluginVersion,attr"`
+	SchemaVersion         string                  `json:"schemaVersion" xml:"schemaVersion,attr"`
+	Hops                  []HopResult             `json:"hops" xml:"hop"`
+	AddressFamily         string                  `json:"addressFamily" xml:"addressFamily,attr"`
+	SourceAddress         string                  `json:"sourceAddress,omitempty" xml:"sourceAddress,attr,omitempty"`
+	SelectedInterface     string                  `json:"selectedInterface,omitempty" xml:"selectedInterface,attr,omitempty"`
+	SelectedSourceIP      string                  `json:"selectedSourceIP,omitempty" xml:"selectedSourceIP,attr,omitempty"`
+	PacketSize            int                     `json:"packetSize" xml:"packetSize,attr"`
+	TOSUsed               int                     `json:"tosUsed" xml:"tosUsed,attr"`
+	FlowID                int                     `json:"flowID" xml:"flowID,attr"`
+	PathMTU               int                     `json:"pathMTU,omitempty" xml:"pathMTU,attr,omitempty"`
+	ProbeSourcePort       int                     `json:"probeSourcePort,omitempty" xml:"probeSourcePort,attr,omitempty"`
+	ProbeDestPort         int                     `json:"probeDestPort,omitempty" xml:"probeDestPort,attr,omitempty"`
+	Timestamp             time.Time               `json:"timestamp" xml:"timestamp,attr"`
+	DestinationReached    bool                    `json:"destinationReached" xml:"destinationReached,attr"`
+	ReachedAtHop          int                     `json:"reachedAtHop" xml:"reachedAtHop,attr"`
+	Truncated             bool                    `json:"truncated" xml:"truncated,attr"`
+	HasMPLS               bool                    `json:"hasMPLS" xml:"hasMPLS,attr"`
+	HasLoop               bool                    `json:"hasLoop" xml:"hasLoop,attr"`
+	LoopsDetected         []LoopInfo              `json:"loopsDetected" xml:"-"`
+	GeoIPEnabled          bool                    `json:"geoipEnabled" xml:"geoipEnabled,attr"`
+	RawOutput             *string                 `json:"rawOutput,omitempty" xml:"rawOutput,omitempty"`
+	Warnings              []string                `json:"warnings,omitempty" xml:"-"`
+	HasAlerts             bool                    `json:"hasAlerts" xml:"hasAlerts,attr"`
+	Alerts                []AlertEntry            `json:"alerts" xml:"-"`
+	SLABreached           bool                    `json:"slaBreached" xml:"slaBreached,attr"`
+	SLAViolations         []SLAViolation          `json:"slaViolations,omitempty" xml:"-"`
+	SLABreachCount        int                     `json:"slaBreachCount,omitempty" xml:"slaBreachCount,attr,omitempty"`
+	SLABreachPeakPercent  float64                 `json:"slaBreachPeakPercent,omitempty" xml:"slaBreachPeakPercent,attr,omitempty"`
+	CommandDurationMs     float64                 `json:"commandDurationMs" xml:"commandDurationMs,attr"`
+	DNSDurationMs         float64                 `json:"dnsDurationMs" xml:"dnsDurationMs,attr"`
+	DNSServer             string                  `json:"dnsServer,omitempty" xml:"dnsServer,attr,omitempty"`
+	SkippedPrivateDNS     int                     `json:"skippedPrivateDNS,omitempty" xml:"skippedPrivateDNS,attr,omitempty"`
+	DNSPending            bool                    `json:"dnsPending,omitempty" xml:"dnsPending,attr,omitempty"`
+	ParseDurationMs       float64                 `json:"parseDurationMs" xml:"parseDurationMs,attr"`
+	IterationCount        int                     `json:"iterationCount,omitempty" xml:"iterationCount,attr,omitempty"`
+	DryRun                string                  `json:"dryRun,omitempty" xml:"dryRun,omitempty"`
+	Cached                bool                    `json:"cached,omitempty" xml:"cached,attr,omitempty"`
+	CacheAge              float64                 `json:"cacheAge,omitempty" xml:"cacheAge,attr,omitempty"`
+	ElapsedTime           time.Duration           `json:"elapsedTime,omitempty" xml:"elapsedTime,attr,omitempty"`
+	PathChanged           bool                    `json:"pathChanged" xml:"pathChanged,attr"`
+	ChangedHops           []int                   `json:"changedHops" xml:"-"`
+	FlapCount             int                     `json:"flapCount,omitempty" xml:"flapCount,attr,omitempty"`
+	RecentFlaps           []FlapEvent             `json:"recentFlaps,omitempty" xml:"-"`
+	Converged             bool                    `json:"converged,omitempty" xml:"converged,attr,omitempty"`
+	ConvergenceIterations int                     `json:"convergenceIterations,omitempty" xml:"convergenceIterations,attr,omitempty"`
+	IterationData         *IterationData          `json:"iteration_data,omitempty" xml:"iterationData,omitempty"`
+	History               []HistoryEntry          `json:"history,omitempty" xml:"-"`
+	RollingStats          map[int]HopStats        `json:"rollingStats,omitempty" xml:"-"`
+	HopAvailability       map[int]HopAvailability `json:"hopAvailability,omitempty" xml:"-"`
+	RTTAnomalies          []RTTAnomaly            `json:"rttAnomalies,omitempty" xml:"-"`
+	Fingerprint           string                  `json:"fingerprint,omitempty" xml:"fingerprint,attr,omitempty"`
+	Paths                 []TracerouteResult      `json:"paths,omitempty" xml:"-"`
+}
+
+// IterationData carries the iteration summary shown by the UI
+type IterationData struct {
+	CanIterate        bool   `json:"can_iterate" xml:"canIterate,attr"`
+	SupportsIteration bool   `json:"supports_iteration" xml:"supportsIteration,attr"`
+	IterationSummary  string `json:"iteration_summary" xml:"summary"`
+	HistorySize       int    `json:"history_size" xml:"historySize,attr"`
+	MaxHistory        int    `json:"max_history" xml:"maxHistory,attr"`
+}
+
+// HistoryEntry is a condensed view of a previous iteration
+type HistoryEntry struct {
+	Iteration     int       `json:"iteration" xml:"number,attr"`
+	Timestamp     time.Time `json:"timestamp" xml:"timestamp,attr"`
+	Host          string    `json:"host" xml:"host,attr"`
+	AddressFamily string    `json:"addressFamily" xml:"addressFamily,attr"`
+	HopCount      int       `json:"hopCount" xml:"hopCount"`
+	LastHop       string    `json:"lastHop" xml:"lastHop"`
+	HopLoss       []HopLoss `json:"hopLoss" xml:"hopLoss"`
+
+	CommandDurationMs float64 `json:"commandDurationMs" xml:"commandDurationMs,attr"`
+	DNSDurationMs     float64 `json:"dnsDurationMs" xml:"dnsDurationMs,attr"`
+	ParseDurationMs   float64 `json:"parseDurationMs" xml:"parseDurationMs,attr"`
+}
+
+// HopLoss records the packet loss of a hop in a previous iteration
+type HopLoss struct {
+	Hop  int     `json:"hop" xml:"hop,attr"`
+	Loss float64 `json:"loss" xml:"loss,attr"`
+}
+
+// MarshalJSON keeps the elapsed time in its human readable form, such as
+// "1.234s", so the output matches what the plugin produced before results
+// were typed. The timestamp is written in RFC 3339 with nanoseconds and hop
+// statuses by their names.
+func (r TracerouteResult) MarshalJSON() ([]byte, error) {
+	type plain TracerouteResult
+	out := struct {
+		plain
+		Timestamp   string `json:"timestamp"`
+		ElapsedTime string `json:"elapsedTime,omitempty"`
+	}{plain: plain(r), Timestamp: r.Timestamp.Format(time.RFC3339Nano)}
+	if r.ElapsedTime != 0 {
+		out.ElapsedTime = r.ElapsedTime.String()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads results written by MarshalJSON as well as the result
+// maps of earlier versions, which have the same keys but may leave the
+// timestamp empty. The elapsed time may also be given in nanoseconds.
+func (r *TracerouteResult) UnmarshalJSON(data []byte) error {
+	type plain TracerouteResult
+	in := struct {
+		*plain
+		Timestamp   json.RawMessage `json:"timestamp"`
+		ElapsedTime json.RawMessage `json:"elapsedTime"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	if len(in.Timestamp) > 0 && string(in.Timestamp) != "null" {
+		var timestamp string
+		if err := json.Unmarshal(in.Timestamp, &timestamp); err != nil {
+			return fmt.Errorf("invalid timestamp %s", in.Timestamp)
+		}
+		if timestamp != "" {
+			t, err := time.Parse(time.RFC3339Nano, timestamp)
+			if err != nil {
+				return fmt.Errorf("invalid timestamp %q: %v", timestamp, err)
+			}
+			r.Timestamp = t
+		}
+	}
+
+	if len(in.ElapsedTime) == 0 || string(in.ElapsedTime) == "null" {
+		return nil
+	}
+	var elapsed string
+	if err := json.Unmarshal(in.ElapsedTime, &elapsed); err != nil {
+		var ns int64
+		if err := json.Unmarshal(in.ElapsedTime, &ns); err != nil {
+			return fmt.Errorf("invalid elapsedTime %s", in.ElapsedTime)
+		}
+		r.ElapsedTime = time.Duration(ns)
+		return nil
+	}
+	d, err := time.ParseDuration(elapsed)
+	if err != nil {
+		return fmt.Errorf("invalid elapsedTime %q: %v", elapsed, err)
+	}
+	r.ElapsedTime = d
+	return nil
+}
+
+// hopIPs maps each hop number to the address that answered it, leaving out
+// hops that did not respond
+func (r TracerouteResult) hopIPs() map[int]string {
+	ips := make(map[int]string, len(r.Hops))
+	for _, hop := range r.Hops {
+		if hop.IP != "*" {
+			ips[hop.Hop] = hop.IP
+		}
+	}
+	return ips
+}
+
+// lastHopIP returns the address of the final hop, if any
+func (r TracerouteResult) lastHopIP() string {
+	if len(r.Hops) == 0 {
+		return ""
+	}
+	return r.Hops[len(r.Hops)-1].IP
+}
+
+// GetHopByIP returns the first hop that answered from ip. Addresses are
+// compared parsed where possible, so differently written forms of an IPv6
+// address match, and whitespace around either side is ignored.
+func (r TracerouteResult) GetHopByIP(ip string) (HopResult, bool) {
+	ip = strings.TrimSpace(ip)
+	if ip == "" || ip == "*" {
+		return HopResult{}, false
+	}
+	addr := net.ParseIP(ip)
+	for _, hop := range r.Hops {
+		hopIP := strings.TrimSpace(hop.IP)
+		if hopIP == ip {
+			return hop, true
+		}
+		if addr == nil {
+			continue
+		}
+		hopAddr := hop.IPAddr
+		if hopAddr == nil {
+			hopAddr = net.ParseIP(hopIP)
+		}
+		if hopAddr != nil && hopAddr.Equal(addr) {
+			return hop, true
+		}
+	}
+	return HopResult{}, false
+}
+
+// GetHopByNumber returns hop n, the first one when a trace holds several
+func (r TracerouteResult) GetHopByNumber(n int) (HopResult, bool) {
+	for _, hop := range r.Hops {
+		if hop.Hop == n {
+			return hop, true
+		}
+	}
+	return HopResult{}, false
+}
+
+// GetFinalHop returns the last hop that answered, false when no hop did
+func (r TracerouteResult) GetFinalHop() (HopResult, bool) {
+	for i := len(r.Hops) - 1; i >= 0; i-- {
+		if r.Hops[i].IP != "*" {
+			return r.Hops[i], true
+		}
+	}
+	return HopResult{}, false
+}
+
+// GetFirstResponsiveHop returns the first hop whose probes were all
+// answered, showing where a trace starts responding after silent hops
+func (r TracerouteResult) GetFirstResponsiveHop() (HopResult, bool) {
+	for _, hop := range r.Hops {
+		if hop.Status == HopStatusOK {
+			return hop, true
+		}
+	}
+	return HopResult{}, false
+}
+
+// IsTargetReachable reports whether the trace reached its destination. A
+// trace in which no hop answered never did.
+func (r TracerouteResult) IsTargetReachable() bool {
+	_, answered := r.GetFinalHop()
+	return r.DestinationReached && answered
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withIPAddrs sets IPAddr of the hops as UnmarshalJSON does
+func withIPAddrs(r TracerouteResult) TracerouteResult {
+	r = cloneResult(r)
+	for i := range r.Hops {
+		r.Hops[i].IPAddr = net.ParseIP(r.Hops[i].IP)
+	}
+	for i := range r.Paths {
+		r.Paths[i] = withIPAddrs(r.Paths[i])
+	}
+	return r
+}
+
+func TestResultJSONRoundTrip(t *testing.T) {
+	want := testResult()
+	var buf bytes.Buffer
+	if err := want.ExportJSON(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"timestamp":"2024-05-01T12:30:15.25Z"`) {
+		t.Errorf("timestamp is not written in RFC 3339 with nanoseconds: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"elapsedTime":"1m30s"`) {
+		t.Errorf("elapsed time is not written as a duration string: %s", buf.String())
+	}
+
+	got, err := ParseJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := withIPAddrs(want); !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed the result:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestResultUnmarshalJSONLegacy(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		timestamp time.Time
+		elapsed   time.Duration
+	}{
+		{"empty timestamp", `{"host":"example.com","timestamp":"","elapsedTime":"2s"}`, time.Time{}, 2 * time.Second},
+		{"no timestamp", `{"host":"example.com"}`, time.Time{}, 0},
+		{"elapsed nanoseconds", `{"host":"example.com","elapsedTime":1500000000}`, time.Time{}, 1500 * time.Millisecond},
+		{"whole seconds", `{"host":"example.com","timestamp":"2024-05-01T12:30:15Z"}`, time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC), 0},
+		{"offset", `{"host":"example.com","timestamp":"2024-05-01T14:30:15.5+02:00"}`, time.Date(2024, 5, 1, 12, 30, 15, 500000000, time.UTC), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r TracerouteResult
+			if err := json.Unmarshal([]byte(tt.data), &r); err != nil {
+				t.Fatal(err)
+			}
+			if r.Host != "example.com" {
+				t.Errorf("got host %q", r.Host)
+			}
+			if !r.Timestamp.Equal(tt.timestamp) {
+				t.Errorf("got timestamp %v, want %v", r.Timestamp, tt.timestamp)
+			}
+			if r.ElapsedTime != tt.elapsed {
+				t.Errorf("got elapsed time %v, want %v", r.ElapsedTime, tt.elapsed)
+			}
+		})
+	}
+
+	for _, data := range []string{
+		`{"timestamp":"yesterday"}`,
+		`{"timestamp":12}`,
+		`{"elapsedTime":"soon"}`,
+		`{"elapsedTime":true}`,
+	} {
+		var r TracerouteResult
+		if err := json.Unmarshal([]byte(data), &r); err == nil {
+			t.Errorf("expected an error for %s", data)
+		}
+	}
+}
+
+func FuzzResultJSONRoundTrip(f *testing.F) {
+	full, err := json.Marshal(testResult())
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(full)
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"host":"example.com","timestamp":"","elapsedTime":1500000000}`))
+	f.Add([]byte(`{"host":"example.com","timestamp":"2024-05-01T14:30:15.123456789+02:00","elapsedTime":"-1.5s"}`))
+	f.Add([]byte(`{"hops":[{"hop":1,"host":"2001:db8::1","rttSamples":[1.5],"status":"PARTIAL"}]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var first TracerouteResult
+		if err := json.Unmarshal(data, &first); err != nil {
+			return
+		}
+		encoded, err := json.Marshal(first)
+		if err != nil {
+			t.Fatalf("failed to encode a decoded result: %v", err)
+		}
+		var second TracerouteResult
+		if err := json.Unmarshal(encoded, &second); err != nil {
+			t.Fatalf("failed to decode %s: %v", encoded, err)
+		}
+		if !second.Timestamp.Equal(first.Timestamp) || second.ElapsedTime != first.ElapsedTime {
+			t.Errorf("got timestamp %v and elapsed time %v, want %v and %v",
+				second.Timestamp, second.ElapsedTime, first.Timestamp, first.ElapsedTime)
+		}
+		again, err := json.Marshal(second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, encoded) {
+			t.Errorf("encoding is not stable:\n%s\n%s", encoded, again)
+		}
+	})
+}
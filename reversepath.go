@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LookupPath traces the path from this machine to dstHost and, when
+// cfg.RemoteAgentURL is set, has the agent there trace the return path to
+// srcHost at the same time, so asymmetric routing shows up. srcHost is the
+// address of this machine as the agent reaches it. The agent is a plugin
+// serving the HTTP API (--http) and traces with the same hop limit, probe
+// count and protocol. Without an agent the reverse path is nil.
+func LookupPath(ctx context.Context, srcHost, dstHost string, cfg Config) ([]HopResult, []HopResult, error) {
+	if cfg.RemoteAgentURL != "" && srcHost == "" {
+		return nil, nil, paramError("srcHost", "is required to trace the return path")
+	}
+
+	// The lookup is a one-off trace, it must not extend a history
+	cfg.HistoryFile = ""
+
+	type trace struct {
+		hops []HopResult
+		err  error
+	}
+	reverse := make(chan trace, 1)
+	if cfg.RemoteAgentURL != "" {
+		go func() {
+			hops, err := remoteTrace(ctx, cfg, srcHost)
+			reverse <- trace{hops, err}
+		}()
+	}
+
+	var forward []HopResult
+	result, err := NewPluginWithConfig(cfg).Execute(ctx, map[string]interface{}{"host": dstHost})
+	if err == nil {
+		forward = result.(TracerouteResult).Hops
+	}
+
+	if cfg.RemoteAgentURL == "" {
+		if err != nil {
+			return nil, nil, err
+		}
+		return forward, nil, nil
+	}
+	back := <-reverse
+	if err != nil {
+		return nil, nil, err
+	}
+	if back.err != nil {
+		return nil, nil, back.err
+	}
+	return forward, back.hops, nil
+}
+
+// remoteTrace asks the agent at cfg.RemoteAgentURL to trace host
+func remoteTrace(ctx context.Context, cfg Config, host string) ([]HopResult, error) {
+	params := map[string]interface{}{"host": host}
+	if cfg.DefaultMaxHops > 0 {
+		params["maxHops"] = cfg.DefaultMaxHops
+	}
+	if cfg.DefaultProbeCount > 0 {
+		params["probeCount"] = cfg.DefaultProbeCount
+	}
+	if cfg.DefaultProtocol != "" {
+		params["protocol"] = cfg.DefaultProtocol
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(cfg.RemoteAgentURL, "/") + "/trace"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach remote agent: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach remote agent: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(msg, &apiErr) == nil && apiErr.Error != "" {
+			msg = []byte(apiErr.Error)
+		}
+		return nil, fmt.Errorf("remote agent failed to trace %s: %s: %s", host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	result, err := ParseJSON(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("remote agent returned an invalid result: %v", err)
+	}
+	return result.Hops, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+// defaultRollingWindow is how many iterations are kept per hop for rolling
+// statistics when no window is configured
+const defaultRollingWindow = 10
+
+// rollingSample holds the measurements of one hop in a single iteration
+type rollingSample struct {
+	samples []float64
+	avg     float64
+	sent    int
+}
+
+// recordRolling appends the hops of an iteration to the per-hop buffers,
+// keeping at most window iterations per hop
+func (p *TraceroutePlugin) recordRolling(result TracerouteResult, window int) {
+	if window < 1 {
+		window = defaultRollingWindow
+	}
+	if p.rolling == nil {
+		p.rolling = map[int][]rollingSample{}
+	}
+	for _, hop := range result.Hops {
+		buf := append(p.rolling[hop.Hop], rollingSample{samples: hop.RTTSamples, avg: hop.RTTAvg, sent: hop.ProbesSent})
+		if len(buf) > window {
+			buf = append([]rollingSample{}, buf[len(buf)-window:]...)
+		}
+		p.rolling[hop.Hop] = buf
+	}
+}
+
+// RollingHopStats computes the RTT and loss statistics of a hop over its
+// most recent window iterations. Only iterations that are still buffered are
+// considered, so window is effectively capped at the configured rolling
+// window.
+func (p *TraceroutePlugin) RollingHopStats(hop int, window int) HopStats {
+	buf := p.rolling[hop]
+	if window > 0 && len(buf) > window {
+		buf = buf[len(buf)-window:]
+	}
+	if len(buf) == 0 {
+		return HopStats{}
+	}
+
+	var samples, averages []float64
+	sent, responded := 0, 0
+	for _, entry := range buf {
+		samples = append(samples, entry.samples...)
+		sent += entry.sent
+		if len(entry.samples) > 0 {
+			averages = append(averages, entry.avg)
+			responded++
+		}
+	}
+
+	min, max, avg, stdDev := rttStats(samples)
+	stats := HopStats{
+		AvgRTT:       avg,
+		MinRTT:       min,
+		MaxRTT:       max,
+		StdDevRTT:    stdDev,
+		Jitter:       jitter(averages),
+		ResponseRate: float64(responded) / float64(len(buf)) * 100,
+	}
+	if sent > 0 {
+		stats.LossPercent = float64(sent-len(samples)) / float64(sent) * 100
+	}
+	return stats
+}
+
+// rollingStats returns the rolling statistics of every buffered hop
+func (p *TraceroutePlugin) rollingStats(window int) map[int]HopStats {
+	stats := make(map[int]HopStats, len(p.rolling))
+	for hop := range p.rolling {
+		stats[hop] = p.RollingHopStats(hop, window)
+	}
+	return stats
+}
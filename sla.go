@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SLA constraints checked against the end to end path, measured at the
+// final hop. A zero value disables the constraint.
+type SLA struct {
+	MaxRTTMs       float64 `json:"maxRttMs" xml:"maxRttMs,attr"`
+	MaxLossPercent float64 `json:"maxLossPercent" xml:"maxLossPercent,attr"`
+	MaxHops        int     `json:"maxHops" xml:"maxHops,attr"`
+}
+
+// SLA constraints reported in SLAViolation.Constraint
+const (
+	SLAMaxRTT  = "maxRttMs"
+	SLAMaxLoss = "maxLossPercent"
+	SLAMaxHops = "maxHops"
+)
+
+// SLAViolation records an SLA constraint a trace did not meet
+type SLAViolation struct {
+	Constraint string  `json:"constraint" xml:"constraint,attr"`
+	Limit      float64 `json:"limit" xml:"limit,attr"`
+	Actual     float64 `json:"actual" xml:"actual,attr"`
+	// Excess is how far the actual value is over the limit
+	Excess float64 `json:"excess" xml:"excess,attr"`
+}
+
+// slaParam reads the "sla" parameter, given as a JSON object or an SLA
+func slaParam(params map[string]interface{}) (*SLA, error) {
+	value, ok := params["sla"]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	if sla, ok := value.(SLA); ok {
+		return &sla, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, paramError("sla", err.Error())
+	}
+	var sla SLA
+	if err := json.Unmarshal(encoded, &sla); err != nil {
+		return nil, paramError("sla", fmt.Sprintf("must be an object with maxRttMs, maxLossPercent and maxHops, got %s", encoded))
+	}
+	if sla.MaxRTTMs < 0 || sla.MaxLossPercent < 0 || sla.MaxHops < 0 {
+		return nil, paramError("sla", "limits must not be negative")
+	}
+	return &sla, nil
+}
+
+// checkSLA returns the constraints of sla the result violates. A path that
+// did not reach its destination has lost every probe at the final hop.
+func checkSLA(result TracerouteResult, sla SLA) []SLAViolation {
+	violations := []SLAViolation{}
+	violate := func(constraint string, limit, actual float64) {
+		if limit > 0 && actual > limit {
+			violations = append(violations, SLAViolation{Constraint: constraint, Limit: limit, Actual: actual, Excess: actual - limit})
+		}
+	}
+
+	loss := 100.0
+	if result.DestinationReached && len(result.Hops) > 0 {
+		final := result.Hops[len(result.Hops)-1]
+		violate(SLAMaxRTT, sla.MaxRTTMs, final.RTTAvg)
+		loss = final.Loss
+	}
+	violate(SLAMaxLoss, sla.MaxLossPercent, loss)
+	if len(result.Hops) > 0 {
+		violate(SLAMaxHops, float64(sla.MaxHops), float64(result.Hops[len(result.Hops)-1].Hop))
+	}
+	return violations
+}
+
+// recordSLA counts the iterations that breached the SLA and the largest
+// excess seen, as a percentage of its limit
+func (p *TraceroutePlugin) recordSLA(violations []SLAViolation) {
+	if len(violations) == 0 {
+		return
+	}
+	p.slaBreachCount++
+	for _, v := range violations {
+		if percent := v.Excess / v.Limit * 100; percent > p.slaBreachPeakPercent {
+			p.slaBreachPeakPercent = percent
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// socketServer answers newline-delimited JSON requests on a Unix socket
+type socketServer struct {
+	plugin *TraceroutePlugin
+
+	// pluginMu is held exclusively by iteration mode and reset requests,
+	// which change the plugin's history, see executeShared
+	pluginMu sync.RWMutex
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// runSocket listens on the Unix socket at path until SIGINT or SIGTERM is
+// received. Each line a client sends is a request as read by --multiplex
+// and is answered with one line holding the result or an error object.
+// Clients may send any number of requests over a connection and connect
+// again after disconnecting. A stale socket file left by an earlier run is
+// replaced.
+func runSocket(plugin *TraceroutePlugin, path string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("failed to listen on %s: file exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return fmt.Errorf("failed to listen on %s: socket is in use", path)
+		}
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", path, err)
+	}
+
+	s := &socketServer{plugin: plugin, conns: map[net.Conn]struct{}{}}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+		s.closeConns()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serve(ctx, conn)
+		}()
+	}
+}
+
+// serve answers the requests of one connection until the client
+// disconnects
+func (s *socketServer) serve(ctx context.Context, conn net.Conn) {
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), requestMaxLineSize)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := enc.Encode(executeRequestLine(ctx, s.plugin, line, &s.pluginMu)); err != nil {
+			// The client went away, its remaining requests are dropped
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+		enc.Encode(map[string]string{"error": fmt.Sprintf("failed to read request: %v", err)})
+	}
+}
+
+// closeConns closes every open connection so their reads return
+func (s *socketServer) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,12 @@
+package main
+
+import "syscall"
+
+// setDontFragment sets the don't fragment bit on subsequent probes and
+// disables fragmentation of IPv6 probes by the local stack
+func setDontFragment(raw syscall.RawConn, ipv6 bool) error {
+	if ipv6 {
+		return setsockoptInt(raw, syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, syscall.IPV6_PMTUDISC_DO)
+	}
+	return setsockoptInt(raw, syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_DO)
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// setDontFragment is not supported on this platform
+func setDontFragment(raw syscall.RawConn, ipv6 bool) error {
+	return fmt.Errorf("setting the don't fragment bit is not supported on %s", runtime.GOOS)
+}
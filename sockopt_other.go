@@ -0,0 +1,33 @@
+//go:build !unix && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// setTTL is not supported on this platform
+func setTTL(raw syscall.RawConn, ttl int, ipv6 bool) error {
+	return fmt.Errorf("setting TTL is not supported on %s", runtime.GOOS)
+}
+
+// setReuseAddr is not supported on this platform
+func setReuseAddr(raw syscall.RawConn) error {
+	return fmt.Errorf("reusing ports is not supported on %s", runtime.GOOS)
+}
+
+// connectionRefused reports whether a TCP connect failed because the target
+// answered with a reset. There is no portable error number here, the error
+// text is matched instead.
+func connectionRefused(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "connection refused")
+}
+
+// messageTooLong reports whether a probe could not be sent because it
+// exceeds the MTU and may not be fragmented
+func messageTooLong(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "message too long")
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setTTL sets the IPv4 time-to-live or IPv6 hop limit used for subsequent
+// probes on the socket
+func setTTL(raw syscall.RawConn, ttl int, ipv6 bool) error {
+	if ipv6 {
+		return setsockoptInt(raw, syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	}
+	return setsockoptInt(raw, syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
+
+// setReuseAddr allows binding a local port still held by a closed
+// connection
+func setReuseAddr(raw syscall.RawConn) error {
+	return setsockoptInt(raw, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+}
+
+func setsockoptInt(raw syscall.RawConn, level, opt, value int) error {
+	var sockErr error
+	err := raw.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), level, opt, value)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// connectionRefused reports whether a TCP connect failed because the target
+// answered with a reset
+func connectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// messageTooLong reports whether a probe could not be sent because it
+// exceeds the MTU and may not be fragmented
+func messageTooLong(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE)
+}
@@ -0,0 +1,64 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Socket options and Winsock errors the syscall package does not define
+const (
+	ipDontFragment   = 14    // IP_DONTFRAGMENT
+	ipv6DontFragment = 14    // IPV6_DONTFRAG
+	wsaeMsgSize      = 10040 // WSAEMSGSIZE
+	wsaeConnRefused  = 10061 // WSAECONNREFUSED
+)
+
+// setTTL sets the IPv4 time-to-live or IPv6 hop limit used for subsequent
+// probes on the socket
+func setTTL(raw syscall.RawConn, ttl int, ipv6 bool) error {
+	if ipv6 {
+		return setsockoptInt(raw, syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	}
+	return setsockoptInt(raw, syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
+
+// setDontFragment sets the don't fragment bit on subsequent probes and
+// disables fragmentation of IPv6 probes by the local stack
+func setDontFragment(raw syscall.RawConn, ipv6 bool) error {
+	if ipv6 {
+		return setsockoptInt(raw, syscall.IPPROTO_IPV6, ipv6DontFragment, 1)
+	}
+	return setsockoptInt(raw, syscall.IPPROTO_IP, ipDontFragment, 1)
+}
+
+// setReuseAddr allows binding a local port still held by a closed
+// connection
+func setReuseAddr(raw syscall.RawConn) error {
+	return setsockoptInt(raw, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+}
+
+func setsockoptInt(raw syscall.RawConn, level, opt, value int) error {
+	var sockErr error
+	err := raw.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(syscall.Handle(fd), level, opt, value)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// connectionRefused reports whether a TCP connect failed because the target
+// answered with a reset. Winsock reports WSAECONNREFUSED rather than the
+// ECONNREFUSED the syscall package defines.
+func connectionRefused(err error) bool {
+	return errors.Is(err, syscall.Errno(wsaeConnRefused)) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// messageTooLong reports whether a probe could not be sent because it
+// exceeds the MTU and may not be fragmented
+func messageTooLong(err error) bool {
+	return errors.Is(err, syscall.Errno(wsaeMsgSize)) || errors.Is(err, syscall.EMSGSIZE)
+}
@@ -0,0 +1,86 @@
+package main
+
+// IterationStats aggregates the iteration results currently stored
+type IterationStats struct {
+	TotalIterations int              `json:"totalIterations"`
+	AverageHopCount float64          `json:"averageHopCount"`
+	MinHopCount     int              `json:"minHopCount"`
+	MaxHopCount     int              `json:"maxHopCount"`
+	PathChangeCount int              `json:"pathChangeCount"`
+	PerHopStats     map[int]HopStats `json:"perHopStats"`
+}
+
+// HopStats aggregates the measurements of one hop number across iterations.
+// Jitter is the mean change of the hop's average RTT between iterations.
+type HopStats struct {
+	AvgRTT       float64 `json:"avgRtt"`
+	MinRTT       float64 `json:"minRtt"`
+	MaxRTT       float64 `json:"maxRtt"`
+	StdDevRTT    float64 `json:"stdDevRtt"`
+	Jitter       float64 `json:"jitter"`
+	LossPercent  float64 `json:"lossPercent"`
+	ResponseRate float64 `json:"responseRate"`
+}
+
+// GetStatistics computes aggregate metrics over the stored iteration results
+func (p *TraceroutePlugin) GetStatistics() IterationStats {
+	stats := IterationStats{
+		TotalIterations: len(p.Results),
+		PerHopStats:     map[int]HopStats{},
+	}
+	if len(p.Results) == 0 {
+		return stats
+	}
+
+	samples := map[int][]float64{}
+	sent := map[int]int{}
+	seen := map[int]int{}
+	responded := map[int]int{}
+	averages := map[int][]float64{}
+	totalHops := 0
+
+	for i, res := range p.Results {
+		hopCount := len(res.Hops)
+		totalHops += hopCount
+		if i == 0 || hopCount < stats.MinHopCount {
+			stats.MinHopCount = hopCount
+		}
+		if hopCount > stats.MaxHopCount {
+			stats.MaxHopCount = hopCount
+		}
+		if i > 0 && len(changedHops(p.Results[i-1], res)) > 0 {
+			stats.PathChangeCount++
+		}
+
+		for _, hop := range res.Hops {
+			samples[hop.Hop] = append(samples[hop.Hop], hop.RTTSamples...)
+			sent[hop.Hop] += hop.ProbesSent
+			seen[hop.Hop]++
+			if hop.IP != "*" {
+				responded[hop.Hop]++
+			}
+			if len(hop.RTTSamples) > 0 {
+				averages[hop.Hop] = append(averages[hop.Hop], hop.RTTAvg)
+			}
+		}
+	}
+	stats.AverageHopCount = float64(totalHops) / float64(len(p.Results))
+
+	for hop, count := range seen {
+		min, max, avg, stdDev := rttStats(samples[hop])
+		hopStats := HopStats{
+			AvgRTT:       avg,
+			MinRTT:       min,
+			MaxRTT:       max,
+			StdDevRTT:    stdDev,
+			Jitter:       jitter(averages[hop]),
+			ResponseRate: float64(responded[hop]) / float64(count) * 100,
+		}
+		if sent[hop] > 0 {
+			hopStats.LossPercent = float64(sent[hop]-len(samples[hop])) / float64(sent[hop]) * 100
+		}
+		stats.PerHopStats[hop] = hopStats
+	}
+
+	return stats
+}
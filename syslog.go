@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogDialTimeout bounds connecting to and writing to the syslog target
+const syslogDialTimeout = 5 * time.Second
+
+// syslogEnterpriseID is the private enterprise number used for the
+// structured data IDs of the per-hop messages (RFC 5612 documentation use)
+const syslogEnterpriseID = 32473
+
+// Syslog severities used for the messages
+const (
+	syslogSeverityWarning = 4
+	syslogSeverityInfo    = 6
+)
+
+// syslogFacilities maps facility names to their RFC 5424 codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSender sends RFC 5424 messages to a syslog server. log/syslog only
+// writes the older BSD format, which has no structured data, and is not
+// available on Windows, so messages are formatted here.
+type syslogSender struct {
+	network  string
+	address  string
+	facility int
+	hostname string
+	appName  string
+}
+
+// newSyslogSender parses a target such as "udp://10.0.0.1:514",
+// "tcp://logs:601" or "10.0.0.1" (UDP on port 514) and a facility name,
+// "user" when empty
+func newSyslogSender(target, facility string) (*syslogSender, error) {
+	network, address := "udp", target
+	if scheme, rest, ok := strings.Cut(target, "://"); ok {
+		network, address = scheme, rest
+	}
+	switch network {
+	case "udp", "tcp":
+	default:
+		return nil, paramError("syslogTarget", fmt.Sprintf("must use udp or tcp, got %q", network))
+	}
+	if address == "" {
+		return nil, paramError("syslogTarget", "has no address")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(address, "["), "]"), "514")
+	}
+
+	if facility == "" {
+		facility = "user"
+	}
+	code, ok := syslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		return nil, paramError("syslogFacility", fmt.Sprintf("is not a syslog facility, got %q", facility))
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSender{
+		network:  network,
+		address:  address,
+		facility: code,
+		hostname: hostname,
+		appName:  strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe"),
+	}, nil
+}
+
+// sendSyslog reports the result to a syslog target
+func sendSyslog(target, facility string, r TracerouteResult) error {
+	sender, err := newSyslogSender(target, facility)
+	if err != nil {
+		return err
+	}
+	return sender.sendResult(r)
+}
+
+// sendResult sends a summary of the trace and one message per alert
+func (s *syslogSender) sendResult(r TracerouteResult) error {
+	now := time.Now()
+	messages := []string{s.format(syslogSeverityInfo, now, "summary", "-", fmt.Sprintf(
+		"traceroute: host=%s hops=%d reached=%t alerts=%t",
+		r.Host, len(r.Hops), r.DestinationReached, r.HasAlerts))}
+
+	for _, alert := range r.Alerts {
+		ip := ""
+		for _, hop := range r.Hops {
+			if hop.Hop == alert.Hop {
+				ip = hop.IP
+				break
+			}
+		}
+		data := fmt.Sprintf(`[hop@%d host="%s" hop="%d" ip="%s" type="%s" threshold="%s" actual="%s"]`,
+			syslogEnterpriseID,
+			syslogParamEscaper.Replace(r.Host),
+			alert.Hop,
+			syslogParamEscaper.Replace(ip),
+			alert.Type,
+			formatFloat(alert.Threshold),
+			formatFloat(alert.Actual))
+		messages = append(messages, s.format(syslogSeverityWarning, now, "alert", data, fmt.Sprintf(
+			"traceroute: host=%s hop=%d %s threshold=%s actual=%s",
+			r.Host, alert.Hop, alert.Type, formatFloat(alert.Threshold), formatFloat(alert.Actual))))
+	}
+
+	conn, err := net.DialTimeout(s.network, s.address, syslogDialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog target %s: %v", s.address, err)
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(syslogDialTimeout))
+
+	for _, msg := range messages {
+		// Stream transports need framing, use octet counting (RFC 6587)
+		if s.network == "tcp" {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			return fmt.Errorf("failed to send syslog message: %v", err)
+		}
+	}
+	return nil
+}
+
+// format builds an RFC 5424 message, data is the structured data or "-"
+func (s *syslogSender) format(severity int, t time.Time, msgID, data, msg string) string {
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		s.facility*8+severity,
+		t.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		s.appName,
+		os.Getpid(),
+		msgID,
+		data,
+		msg)
+}
+
+// syslogParamEscaper escapes structured data parameter values
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otlpExportTimeout bounds the upload of the spans of one execution
+const otlpExportTimeout = 5 * time.Second
+
+// tracerName is the instrumentation scope of the spans of the plugin
+const tracerName = "github.com/NetScout-Go/Plugin_traceroute"
+
+// newTracerProvider creates a tracer provider uploading spans to an
+// OpenTelemetry collector using OTLP over HTTP, at an endpoint such as
+// "http://collector:4318". Spans are batched until Shutdown. The error
+// describes the endpoint, as in "is not a valid URL".
+func newTracerProvider(ctx context.Context, endpoint string) (*sdktrace.TracerProvider, error) {
+	tracesURL := otlpTracesURL(endpoint)
+	if u, err := url.Parse(tracesURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("is not a valid URL: %q", endpoint)
+	}
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(tracesURL),
+		otlptracehttp.WithTimeout(otlpExportTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("can not be exported to: %v", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName("netscout-traceroute"))),
+	), nil
+}
+
+// otlpTracesURL returns the traces URL of an OTLP/HTTP endpoint given with
+// or without scheme and path
+func otlpTracesURL(endpoint string) string {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	return endpoint
+}
+
+// startSpan starts a span as a child of the span in ctx, using the tracer
+// provider of that span. Without a span in ctx the no-op provider is used,
+// so tracing costs nothing unless Execute was given an otlpEndpoint or
+// called within a trace of the caller.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed when err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeTraceroute puts a traceroute script printing a three hop trace to
+// 192.0.2.10 first in PATH
+func fakeTraceroute(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake traceroute is a shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+cat <<'EOF'
+traceroute to 192.0.2.10 (192.0.2.10), 30 hops max, 60 byte packets
+ 1  192.0.2.1  0.500 ms  0.400 ms  0.300 ms
+ 2  * * *
+ 3  192.0.2.10  8.000 ms  7.000 ms  9.000 ms
+EOF
+`
+	if err := os.WriteFile(filepath.Join(dir, "traceroute"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// otlpCollector records the spans posted to /v1/traces
+type otlpCollector struct {
+	mu        sync.Mutex
+	resources []*tracepb.ResourceSpans
+}
+
+func (c *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.resources = append(c.resources, req.GetResourceSpans()...)
+	c.mu.Unlock()
+
+	resp, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(resp)
+}
+
+func (c *otlpCollector) spans() []*tracepb.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var spans []*tracepb.Span
+	for _, resource := range c.resources {
+		for _, scope := range resource.GetScopeSpans() {
+			spans = append(spans, scope.GetSpans()...)
+		}
+	}
+	return spans
+}
+
+// stubResolver names every hop "router"
+type stubResolver struct{}
+
+func (stubResolver) ResolveHop(ctx context.Context, ip string) (HopEnrichment, error) {
+	return HopEnrichment{Hostname: "router"}, nil
+}
+
+func attributeValues(attrs []*commonpb.KeyValue) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, attr := range attrs {
+		switch v := attr.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			values[attr.GetKey()] = v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			values[attr.GetKey()] = v.IntValue
+		case *commonpb.AnyValue_DoubleValue:
+			values[attr.GetKey()] = v.DoubleValue
+		case *commonpb.AnyValue_BoolValue:
+			values[attr.GetKey()] = v.BoolValue
+		}
+	}
+	return values
+}
+
+func TestExecuteExportsSpans(t *testing.T) {
+	fakeTraceroute(t)
+	collector := &otlpCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	p := NewPlugin()
+	p.Resolver = stubResolver{}
+	if _, err := p.Execute(context.Background(), map[string]interface{}{
+		"host":         "192.0.2.10",
+		"otlpEndpoint": server.URL,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := collector.spans()
+	byName := map[string]*tracepb.Span{}
+	var names []string
+	for _, span := range spans {
+		byName[span.GetName()] = span
+		names = append(names, span.GetName())
+	}
+	slices.Sort(names)
+	want := []string{"traceroute.command", "traceroute.dns_resolution", "traceroute.dns_resolution", "traceroute.execute", "traceroute.parse"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got spans %v, want %v", names, want)
+	}
+
+	root := byName["traceroute.execute"]
+	if len(root.GetParentSpanId()) != 0 {
+		t.Error("traceroute.execute has a parent span")
+	}
+	for _, span := range spans {
+		if span == root {
+			continue
+		}
+		if !slices.Equal(span.GetTraceId(), root.GetTraceId()) || !slices.Equal(span.GetParentSpanId(), root.GetSpanId()) {
+			t.Errorf("%s is not a child of traceroute.execute", span.GetName())
+		}
+	}
+	if got := attributeValues(root.GetAttributes())["host"]; got != "192.0.2.10" {
+		t.Errorf("got host attribute %v", got)
+	}
+
+	wantHops := []map[string]interface{}{
+		{"hop.number": int64(1), "hop.ip": "192.0.2.1", "hop.rtt_ms": 0.4},
+		{"hop.number": int64(2), "hop.ip": "*", "hop.rtt_ms": 0.0},
+		{"hop.number": int64(3), "hop.ip": "192.0.2.10", "hop.rtt_ms": 8.0},
+	}
+	events := root.GetEvents()
+	if len(events) != len(wantHops) {
+		t.Fatalf("got %d events, want one per hop", len(events))
+	}
+	for i, event := range events {
+		got := attributeValues(event.GetAttributes())
+		if event.GetName() != "hop" || got["hop.number"] != wantHops[i]["hop.number"] || got["hop.ip"] != wantHops[i]["hop.ip"] {
+			t.Errorf("event %d: got %s %v, want hop %v", i, event.GetName(), got, wantHops[i])
+		}
+		if rtt, _ := got["hop.rtt_ms"].(float64); math.Abs(rtt-wantHops[i]["hop.rtt_ms"].(float64)) > 1e-9 {
+			t.Errorf("event %d: got rtt %v, want %v", i, got["hop.rtt_ms"], wantHops[i]["hop.rtt_ms"])
+		}
+	}
+
+	collector.mu.Lock()
+	service := attributeValues(collector.resources[0].GetResource().GetAttributes())["service.name"]
+	collector.mu.Unlock()
+	if service != "netscout-traceroute" {
+		t.Errorf("got service name %v", service)
+	}
+}
+
+func TestExecuteJoinsCallerTrace(t *testing.T) {
+	fakeTraceroute(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, parent := provider.Tracer("caller").Start(context.Background(), "caller")
+
+	if _, err := NewPlugin().Execute(ctx, map[string]interface{}{"host": "192.0.2.10", "resolveDNS": false}); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "traceroute.execute" {
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Error("traceroute.execute is not a child of the span of the caller")
+			}
+			return
+		}
+	}
+	t.Error("no traceroute.execute span was recorded in the trace of the caller")
+}
+
+func TestExecuteInvalidOTLPEndpoint(t *testing.T) {
+	_, err := NewPlugin().Execute(context.Background(), map[string]interface{}{"host": "192.0.2.10", "otlpEndpoint": "ftp://collector"})
+	if code := errorCode(err); code != ErrInvalidParam {
+		t.Errorf("got %v (%s), want %s", err, code, ErrInvalidParam)
+	}
+}
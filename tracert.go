@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tracertSubMillisecond is the RTT recorded for replies tracert reports as
+// "<1 ms"
+const tracertSubMillisecond = 0.5
+
+// parseTracertOutput parses the output of the Windows tracert command, where
+// each hop line lists the RTT columns before the address:
+//
+//	1    <1 ms    <1 ms    <1 ms  192.168.1.1
+//	2     *        *        *     Request timed out.
+//	3  10.0.0.1  reports: Destination host unreachable.
+//
+// The unit and the messages are localized, so only the hop number, the RTT
+// values and the address are relied upon. A line with an address but no
+// RTT columns is a router reporting the target unreachable.
+func parseTracertOutput(output string) ([]HopResult, error) {
+	hops := []HopResult{}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+		hopNumber, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue // Header, footer and error lines
+		}
+
+		hopIP := "*"
+		samples := []float64{}
+		sent := 0
+		for _, part := range parts[1:] {
+			switch {
+			case part == "*":
+				sent++
+			case strings.HasPrefix(part, "<"):
+				samples = append(samples, tracertSubMillisecond)
+				sent++
+			default:
+				// Without -d the address follows the name in brackets
+				addr := strings.TrimSuffix(strings.TrimPrefix(part, "["), "]")
+				if net.ParseIP(addr) != nil {
+					hopIP = addr
+					continue
+				}
+				// Some locales omit the space before the unit
+				rtt, err := strconv.ParseFloat(strings.TrimRightFunc(part, unicode.IsLetter), 64)
+				if err == nil {
+					samples = append(samples, rtt)
+					sent++
+				}
+			}
+		}
+
+		hop := newHop(hopNumber, hopIP, hopIP, samples, sent, "icmp")
+		if sent == 0 && hopIP != "*" {
+			// The router answered the probe with an unreachable message,
+			// tracert prints no RTT for it
+			hop.ProbesSent = 1
+			hop.Status = HopStatusUnreachable
+		}
+		hops = append(hops, hop)
+	}
+
+	if len(hops) == 0 {
+		return nil, errors.New("no hops found in tracert output")
+	}
+	return hops, nil
+}
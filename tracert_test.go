@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// tracertOutput is tracert output captured on Windows 10, with -d left out
+// so named hops carry their address in brackets
+const tracertOutput = `
+Tracing route to example.com [93.184.216.34]
+over a maximum of 30 hops:
+
+  1    <1 ms    <1 ms    <1 ms  192.168.1.1
+  2     8 ms     7 ms     9 ms  10.20.0.1
+  3     *        *        *     Request timed out.
+  4    12 ms     *       14 ms  core1.example.net [203.0.113.9]
+  5  203.0.113.77  reports: Destination host unreachable.
+
+Trace complete.
+`
+
+func TestParseTracertOutput(t *testing.T) {
+	hops, err := parseTracertOutput(tracertOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		hop        int
+		ip         string
+		samples    []float64
+		probesSent int
+		loss       float64
+		status     HopStatus
+	}{
+		{1, "192.168.1.1", []float64{tracertSubMillisecond, tracertSubMillisecond, tracertSubMillisecond}, 3, 0, HopStatusOK},
+		{2, "10.20.0.1", []float64{8, 7, 9}, 3, 0, HopStatusOK},
+		{3, "*", []float64{}, 3, 100, HopStatusNoResponse},
+		{4, "203.0.113.9", []float64{12, 14}, 3, 100.0 / 3, HopStatusPartial},
+		{5, "203.0.113.77", []float64{}, 1, 0, HopStatusUnreachable},
+	}
+	if len(hops) != len(want) {
+		t.Fatalf("got %d hops, want %d", len(hops), len(want))
+	}
+	for i, w := range want {
+		got := hops[i]
+		if got.Hop != w.hop || got.IP != w.ip || got.ProbesSent != w.probesSent || got.Status != w.status {
+			t.Errorf("hop %d: got hop %d, ip %s, %d probes, status %v; want hop %d, ip %s, %d probes, status %v",
+				i+1, got.Hop, got.IP, got.ProbesSent, got.Status, w.hop, w.ip, w.probesSent, w.status)
+		}
+		if !reflect.DeepEqual(got.RTTSamples, w.samples) {
+			t.Errorf("hop %d: got samples %v, want %v", i+1, got.RTTSamples, w.samples)
+		}
+		if diff := got.Loss - w.loss; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("hop %d: got loss %v, want %v", i+1, got.Loss, w.loss)
+		}
+	}
+}
+
+func TestParseTracertOutputLocalized(t *testing.T) {
+	// German tracert, some locales also leave out the space before the unit
+	output := `
+Routenverfolgung zu 2001:db8::10 über maximal 30 Hops
+
+  1     1ms     <1ms     2ms  2001:db8::1
+  2     *        *        *     Zeitüberschreitung der Anforderung.
+  3  2001:db8::ff  meldet: Zielhost nicht erreichbar.
+
+Ablaufverfolgung beendet.
+`
+	hops, err := parseTracertOutput(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hops) != 3 {
+		t.Fatalf("got %d hops, want 3", len(hops))
+	}
+	if got, want := hops[0].RTTSamples, []float64{1, tracertSubMillisecond, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hop 1: got samples %v, want %v", got, want)
+	}
+	if hops[0].IP != "2001:db8::1" {
+		t.Errorf("hop 1: got ip %s, want 2001:db8::1", hops[0].IP)
+	}
+	if hops[1].Status != HopStatusNoResponse || hops[1].Loss != 100 {
+		t.Errorf("hop 2: got status %v and loss %v, want NO RESPONSE and 100", hops[1].Status, hops[1].Loss)
+	}
+	if hops[2].Status != HopStatusUnreachable || hops[2].IP != "2001:db8::ff" {
+		t.Errorf("hop 3: got status %v at %s, want UNREACHABLE at 2001:db8::ff", hops[2].Status, hops[2].IP)
+	}
+}
+
+func TestParseTracertOutputNoHops(t *testing.T) {
+	if _, err := parseTracertOutput("Unable to resolve target system name nowhere.invalid.\n"); err == nil {
+		t.Error("expected an error for output without hops")
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// ValidationError reports a parameter that can not be used for a trace
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// validateParams checks the parameters that do not depend on the target
+// address before anything is sent, so callers learn which parameter is
+// wrong instead of getting an error from the traceroute binary
+func validateParams(params map[string]interface{}) error {
+	in := paramReader{params: params}
+	host, _ := params["host"].(string)
+	if host == "" {
+		return paramError("host", "is required")
+	}
+	if !validHost(host) {
+		return paramError("host", fmt.Sprintf("must be a hostname or IP address, got %q", host))
+	}
+
+	ranges := []struct {
+		field    string
+		min, max float64
+	}{
+		{"maxHops", 1, 255},
+		{"probeCount", 1, 10},
+		{"packetSize", 60, 65535},
+		{"tos", 0, 255},
+		{"sourcePort", 0, 65535},
+		{"destPort", 1, 65535},
+	}
+	for _, r := range ranges {
+		value, ok := in.float(r.field)
+		if in.err != nil {
+			return in.err
+		}
+		if ok && (value < r.min || value > r.max) {
+			return paramError(r.field, fmt.Sprintf("must be between %v and %v, got %v", r.min, r.max, value))
+		}
+	}
+
+	if protocol, ok := params["protocol"].(string); ok {
+		switch protocol {
+		case "", "icmp", "udp", "tcp":
+		default:
+			return paramError("protocol", fmt.Sprintf("must be icmp, udp or tcp, got %q", protocol))
+		}
+	}
+
+	if _, err := slaParam(params); err != nil {
+		return err
+	}
+
+	if server, _ := params["dnsServer"].(string); server != "" && !validDNSServer(server) {
+		return paramError("dnsServer", fmt.Sprintf("must be an ip:port address such as 192.168.1.53:53, got %q", server))
+	}
+
+	if target, _ := params["syslogTarget"].(string); target != "" {
+		facility, _ := params["syslogFacility"].(string)
+		if _, err := newSyslogSender(target, facility); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeHost reduces what users commonly pass as the host to the host
+// itself: surrounding whitespace is trimmed and a URL such as
+// "https://example.com/path", or a host followed by a path, gives its host
+// name without port or path. The result must be a hostname or IP address.
+func normalizeHost(raw string) (string, error) {
+	host := strings.TrimSpace(raw)
+	if host == "" {
+		return "", paramError("host", "is required")
+	}
+	if strings.Contains(host, "://") || strings.Contains(host, "/") {
+		target := host
+		if !strings.Contains(target, "://") {
+			target = "//" + target
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", paramError("host", fmt.Sprintf("must be a hostname or IP address, got %q", raw))
+		}
+		host = u.Hostname()
+	}
+	if _, err := idnaToASCII(host); err != nil {
+		return "", paramError("host", fmt.Sprintf("is not a valid internationalized domain name: %v", err))
+	}
+	if !validHost(host) {
+		return "", paramError("host", fmt.Sprintf("must be a hostname or IP address, got %q", raw))
+	}
+	return host, nil
+}
+
+// withNormalizedHost returns params with the host replaced by its
+// normalized form, params itself is not modified
+func withNormalizedHost(params map[string]interface{}) (map[string]interface{}, error) {
+	raw, _ := params["host"].(string)
+	host, err := normalizeHost(raw)
+	if err != nil {
+		return nil, err
+	}
+	if host == raw {
+		return params, nil
+	}
+	normalized := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		normalized[k] = v
+	}
+	normalized["host"] = host
+	return normalized, nil
+}
+
+// validHost reports whether host is an IP address, optionally bracketed or
+// with an IPv6 zone, or a syntactically valid hostname, internationalized
+// names included
+func validHost(host string) bool {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if ip, _, _ := strings.Cut(host, "%"); net.ParseIP(ip) != nil {
+		return true
+	}
+	host, err := idnaToASCII(host)
+	if err != nil {
+		return false
+	}
+
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// idnaToASCII converts an internationalized domain name to its ASCII
+// compatible form for lookups. ASCII names, IP addresses included, are
+// returned unchanged.
+func idnaToASCII(host string) (string, error) {
+	for i := 0; i < len(host); i++ {
+		if host[i] >= utf8.RuneSelf {
+			return idna.Lookup.ToASCII(host)
+		}
+	}
+	return host, nil
+}
+
+// paramDefault describes how a known parameter is coerced and the value
+// the trace uses when it is absent
+type paramDefault struct {
+	key   string
+	kind  string // "number", "boolean" or "string"
+	value interface{}
+}
+
+// paramDefaults lists the parameters understood by Execute with the
+// defaults performTraceroute and the iteration mode fall back to
+func (p *TraceroutePlugin) paramDefaults(params map[string]interface{}) []paramDefault {
+	maxHops := p.Config.DefaultMaxHops
+	if maxHops < 1 {
+		maxHops = 30
+	}
+	probeCount := p.Config.DefaultProbeCount
+	if probeCount < 1 {
+		probeCount = 3
+	}
+	// The real minimum depends on the resolved address family, only IPv6
+	// literals are known to need the larger one up front
+	packetSize := 60
+	if host, _ := params["host"].(string); strings.Contains(host, ":") {
+		packetSize = 1280
+	}
+	// The destination port defaults to the older port parameter
+	destPort := 80.0
+	if port, ok, err := coerceFloat64(params["port"]); err == nil && ok {
+		destPort = port
+	}
+	rollingWindow := p.Config.RollingWindow
+	if rollingWindow < 1 {
+		rollingWindow = defaultRollingWindow
+	}
+
+	return []paramDefault{
+		{"host", "string", ""},
+		{"maxHops", "number", float64(maxHops)},
+		{"firstHop", "number", 1.0},
+		{"probeCount", "number", float64(probeCount)},
+		{"protocol", "string", p.Config.DefaultProtocol},
+		{"port", "number", 80.0},
+		{"sourcePort", "number", 0.0},
+		{"destPort", "number", destPort},
+		{"packetSize", "number", float64(packetSize)},
+		{"tos", "number", 0.0},
+		{"waitTime", "number", p.Config.WaitTime.Seconds()},
+		{"useNative", "boolean", false},
+		{"sourceAddress", "string", ""},
+		{"sourceInterface", "string", ""},
+		{"interfaceAutoSelect", "string", ""},
+		{"resolveDNS", "boolean", p.Config.ResolveDNS},
+		{"dnsParallelism", "number", float64(p.Config.DNSParallelism)},
+		{"dnsTimeout", "number", defaultDNSTimeout.Seconds()},
+		{"negativeCacheTTL", "number", p.Config.NegativeCacheTTL.Seconds()},
+		{"dnsServer", "string", ""},
+		{"skipPrivateDNS", "boolean", false},
+		{"asyncDNS", "boolean", false},
+		{"includeASN", "boolean", false},
+		{"geoipDBPath", "string", p.Config.GeoIPDBPath},
+		{"continueOnLoop", "boolean", false},
+		{"includeRawOutput", "boolean", true},
+		{"parisTraceroute", "boolean", false},
+		{"flowID", "number", 0.0},
+		{"discoverAllPaths", "boolean", false},
+		{"pathCount", "number", float64(defaultPathCount)},
+		{"mtuDiscovery", "boolean", false},
+		{"dontFragment", "boolean", false},
+		{"retryTimeouts", "number", 0.0},
+		{"rttThreshold", "number", 0.0},
+		{"lossThreshold", "number", 0.0},
+		{"overallTimeout", "number", p.Config.DefaultTimeout.Seconds()},
+		{"continueToIterate", "boolean", false},
+		{"maxHistory", "number", float64(p.Config.MaxHistory)},
+		{"rollingWindow", "number", float64(rollingWindow)},
+		{"includeRollingStats", "boolean", false},
+		{"includeAvailability", "boolean", false},
+		{"convergenceWindow", "number", float64(defaultConvergenceWindow)},
+		{"rttTolerance", "number", defaultRTTTolerance},
+		{"historyFile", "string", p.Config.HistoryFile},
+		{"cacheEnabled", "boolean", false},
+		{"cacheTTL", "number", defaultCacheTTL.Seconds()},
+		{"otlpEndpoint", "string", ""},
+		{"syslogTarget", "string", ""},
+		{"syslogFacility", "string", "user"},
+		{"influxdbURL", "string", ""},
+		{"influxdbToken", "string", ""},
+		{"influxdbOrg", "string", ""},
+		{"influxdbBucket", "string", ""},
+	}
+}
+
+// prepareParams applies the parameter defaults to params, normalizes the
+// host and validates the result. It is the first step of Execute, servers
+// call it as well to reject a request before answering it.
+func (p *TraceroutePlugin) prepareParams(params map[string]interface{}) (map[string]interface{}, error) {
+	params, err := withNormalizedHost(p.withParamDefaults(params))
+	if err != nil {
+		return nil, err
+	}
+	if err := validateParams(params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// normalizeParams validates params and returns a copy with every known
+// parameter converted to its JSON type and absent ones set to their
+// default. Unknown parameters are passed through unchanged.
+func (p *TraceroutePlugin) normalizeParams(params map[string]interface{}) (map[string]interface{}, error) {
+	params, err := p.prepareParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		normalized[k] = v
+	}
+	in := paramReader{params: params}
+	for _, d := range p.paramDefaults(params) {
+		normalized[d.key] = d.value
+		switch d.kind {
+		case "number":
+			if v, ok := in.float(d.key); ok {
+				normalized[d.key] = v
+			}
+		case "boolean":
+			if v, ok := in.bool(d.key); ok {
+				normalized[d.key] = v
+			}
+		default:
+			if v, ok := params[d.key].(string); ok {
+				normalized[d.key] = v
+			}
+		}
+	}
+	if in.err != nil {
+		return nil, in.err
+	}
+	return normalized, nil
+}
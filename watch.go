@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultWatchInterval is the pause between traces when --watch is
+	// given without a value
+	defaultWatchInterval = 10 * time.Second
+
+	// ansiClearScreen moves the cursor home and clears the terminal
+	ansiClearScreen = "\033[H\033[2J"
+)
+
+// runWatch traces repeatedly in iteration mode, writing each result as it
+// completes, until SIGINT or SIGTERM is received or the overall timeout
+// expires. The aggregate statistics are printed before returning.
+func runWatch(plugin *TraceroutePlugin, params map[string]interface{}, interval time.Duration, output string, pretty bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The overall timeout bounds the whole watch, not just one trace
+	in := paramReader{params: params}
+	if overallTimeout, ok := in.float("overallTimeout"); ok && overallTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(overallTimeout*float64(time.Second)))
+		defer cancel()
+		delete(params, "overallTimeout")
+	}
+	if in.err != nil {
+		return in.err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Each record is flushed as soon as its iteration completes
+	var records *ndjsonWriter
+	if output == "ndjson" {
+		records = newNDJSONWriter(os.Stdout)
+	}
+
+	params["continueToIterate"] = true
+	for ctx.Err() == nil {
+		result, err := plugin.Execute(ctx, params)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			errorRecord := map[string]string{"error": err.Error()}
+			if records != nil {
+				records.Write(errorRecord)
+			} else {
+				errorJSON, _ := json.Marshal(errorRecord)
+				fmt.Println(string(errorJSON))
+			}
+		} else if traceResult, ok := result.(TracerouteResult); ok {
+			if output == "table" {
+				fmt.Print(ansiClearScreen)
+			}
+			if records != nil {
+				err = records.Write(traceResult)
+			} else if output == "dot" {
+				// Overlay the paths of every stored iteration
+				err = ExportDOT(os.Stdout, plugin.Results...)
+			} else {
+				err = writeResult(os.Stdout, traceResult, output, pretty)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+
+	if records != nil {
+		return records.Write(plugin.GetStatistics())
+	}
+	statsJSON, err := json.Marshal(plugin.GetStatistics())
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(statsJSON))
+	return nil
+}